@@ -0,0 +1,155 @@
+// Package otelzlog async holds the queue that exports log records off the
+// logging goroutine
+package otelzlog
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+// DropPolicy selects what happens when a record is logged while the queue of an
+// asynchronous [Hook] is full. See [WithAsync].
+type DropPolicy int
+
+const (
+	// DropOldest drops the oldest queued record to make room for the new one.
+	DropOldest DropPolicy = iota
+	// DropNewest drops the new record.
+	DropNewest
+	// Block blocks the logging goroutine until there is room in the queue.
+	Block
+)
+
+// flushPollInterval is how often Flush checks whether the queue has drained.
+const flushPollInterval = 5 * time.Millisecond
+
+type queuedRecord struct {
+	ctx    context.Context
+	record otelLog.Record
+}
+
+// asyncEmitter emits records from a worker goroutine, serving a bounded queue.
+type asyncEmitter struct {
+	emit   func(context.Context, otelLog.Record)
+	policy DropPolicy
+	queue  chan queuedRecord
+
+	// pending counts the records that are queued or being emitted
+	pending atomic.Int64
+	dropped atomic.Uint64
+
+	// mu is held for writing to close the queue, and for reading to send on it
+	mu     sync.RWMutex
+	closed bool
+	// done is closed once the worker has emitted the last record of the closed queue
+	done chan struct{}
+}
+
+func newAsyncEmitter(emit func(context.Context, otelLog.Record), queueSize int, policy DropPolicy) *asyncEmitter {
+	a := &asyncEmitter{
+		emit:   emit,
+		policy: policy,
+		queue:  make(chan queuedRecord, max(queueSize, 1)),
+		done:   make(chan struct{}),
+	}
+
+	go a.run()
+
+	return a
+}
+
+func (a *asyncEmitter) run() {
+	defer close(a.done)
+
+	for item := range a.queue {
+		a.emit(item.ctx, item.record)
+		a.pending.Add(-1)
+	}
+}
+
+// enqueue queues the record according to the drop policy. The context is detached
+// from its cancellation, as the record is emitted after the logging call returns.
+// Records logged after shutdown are dropped.
+func (a *asyncEmitter) enqueue(ctx context.Context, record otelLog.Record) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		a.dropped.Add(1)
+		return
+	}
+
+	item := queuedRecord{ctx: context.WithoutCancel(ctx), record: record}
+	a.pending.Add(1)
+
+	switch a.policy {
+	case Block:
+		a.queue <- item
+
+	case DropNewest:
+		select {
+		case a.queue <- item:
+		default:
+			a.drop()
+		}
+
+	default:
+		for {
+			select {
+			case a.queue <- item:
+				return
+			default:
+			}
+
+			// the queue is full, make room by dropping the oldest record
+			select {
+			case <-a.queue:
+				a.drop()
+			default:
+			}
+		}
+	}
+}
+
+func (a *asyncEmitter) drop() {
+	a.dropped.Add(1)
+	a.pending.Add(-1)
+}
+
+// flush waits until every queued record has been emitted, or ctx is done.
+func (a *asyncEmitter) flush(ctx context.Context) error {
+	ticker := time.NewTicker(flushPollInterval)
+	defer ticker.Stop()
+
+	for a.pending.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// shutdown closes the queue and waits until the worker has emitted every queued record
+// and stopped, or ctx is done.
+func (a *asyncEmitter) shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
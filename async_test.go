@@ -0,0 +1,155 @@
+package otelzlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otelLog "go.opentelemetry.io/otel/log"
+	logNoop "go.opentelemetry.io/otel/log/noop"
+)
+
+// blockingLogger holds every Emit until release is closed, so that the queue of an
+// asynchronous hook can be filled up.
+type blockingLogger struct {
+	otelLog.Logger
+	release chan struct{}
+}
+
+func (l *blockingLogger) Emit(ctx context.Context, record otelLog.Record) {
+	<-l.release
+	l.Logger.Emit(ctx, record)
+}
+
+func TestHookAsync(t *testing.T) {
+	recorder := newRecorder(t)
+	hook := &Hook{otelLogger: recorder.Logger("test")}
+
+	var emitErr error
+	hook.async = newAsyncEmitter(func(ctx context.Context, record otelLog.Record) {
+		emitErr = ctx.Err()
+		hook.otelLogger.Emit(ctx, record)
+	}, 10, Block)
+	logger := zerolog.New(new(bytes.Buffer)).Hook(hook)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	logger.Info().Ctx(ctx).Msg("test log")
+	cancel()
+
+	require.NoError(t, hook.Flush(t.Context()))
+	require.Len(t, recorder.Records(), 1)
+	assert.Equal(t, "test log", recorder.Records()[0].Body().AsString())
+	assert.NoError(t, emitErr, "the record must be emitted with a context that is not cancelled")
+	assert.Zero(t, hook.DroppedRecords())
+}
+
+func TestHookAsyncDropPolicy(t *testing.T) {
+	tests := []struct {
+		policy   DropPolicy
+		expected []string
+	}{
+		{DropNewest, []string{"0", "1", "2"}},
+		{DropOldest, []string{"0", "3", "4"}},
+	}
+
+	for _, tt := range tests {
+		recorder := newRecorder(t)
+		logger := &blockingLogger{Logger: recorder.Logger("test"), release: make(chan struct{})}
+		hook := &Hook{otelLogger: logger}
+		hook.async = newAsyncEmitter(logger.Emit, 2, tt.policy)
+
+		// the worker holds on to the first record, and the queue holds the next two
+		hook.emit(t.Context(), recordWithBody("0"))
+		require.Eventually(t, func() bool { return len(hook.async.queue) == 0 }, time.Second, time.Millisecond)
+		for _, body := range []string{"1", "2", "3", "4"} {
+			hook.emit(t.Context(), recordWithBody(body))
+		}
+
+		assert.Equal(t, uint64(2), hook.DroppedRecords())
+
+		close(logger.release)
+		require.NoError(t, hook.Flush(t.Context()))
+
+		var bodies []string
+		for _, record := range recorder.Records() {
+			bodies = append(bodies, record.Body().AsString())
+		}
+		assert.Equal(t, tt.expected, bodies)
+	}
+}
+
+func TestHookAsyncFlushTimeout(t *testing.T) {
+	logger := &blockingLogger{Logger: newRecorder(t).Logger("test"), release: make(chan struct{})}
+	defer close(logger.release)
+
+	hook := &Hook{otelLogger: logger}
+	hook.async = newAsyncEmitter(logger.Emit, 1, Block)
+	hook.emit(t.Context(), recordWithBody("test log"))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, hook.Flush(ctx), context.DeadlineExceeded)
+}
+
+func TestHookAsyncShutdown(t *testing.T) {
+	recorder := newRecorder(t)
+	logger := &blockingLogger{Logger: recorder.Logger("test"), release: make(chan struct{})}
+	hook := &Hook{otelLogger: logger}
+	hook.async = newAsyncEmitter(logger.Emit, 10, Block)
+
+	for _, body := range []string{"0", "1", "2"} {
+		hook.emit(t.Context(), recordWithBody(body))
+	}
+
+	// the worker is still emitting the queued records
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, hook.Shutdown(ctx), context.DeadlineExceeded)
+
+	close(logger.release)
+	require.NoError(t, hook.Shutdown(t.Context()))
+	assert.Len(t, recorder.Records(), 3)
+
+	select {
+	case <-hook.async.done:
+	default:
+		t.Fatal("the worker must have stopped")
+	}
+
+	// records logged after shutdown are dropped
+	hook.emit(t.Context(), recordWithBody("3"))
+	assert.Len(t, recorder.Records(), 3)
+	assert.Equal(t, uint64(1), hook.DroppedRecords())
+	assert.NoError(t, hook.Flush(t.Context()))
+	assert.NoError(t, hook.Shutdown(t.Context()))
+}
+
+func TestHookSyncFlush(t *testing.T) {
+	hook := &Hook{otelLogger: newRecorder(t).Logger("test")}
+
+	assert.NoError(t, hook.Flush(t.Context()))
+	assert.NoError(t, hook.Shutdown(t.Context()))
+	assert.Zero(t, hook.DroppedRecords())
+}
+
+func TestFlushFromContext(t *testing.T) {
+	assert.NoError(t, Flush(t.Context()))
+	assert.NoError(t, Shutdown(t.Context()))
+	assert.Zero(t, DroppedRecords(t.Context()))
+
+	ctx := New(t.Context(), "test", WithAsync(10, DropNewest), WithLoggerProvider(logNoop.NewLoggerProvider()))
+	require.NotNil(t, hookFromContext(ctx))
+	assert.NotNil(t, hookFromContext(ctx).async)
+	assert.NoError(t, Flush(ctx))
+	assert.NoError(t, Shutdown(ctx))
+}
+
+func recordWithBody(body string) otelLog.Record {
+	var record otelLog.Record
+	record.SetBody(otelLog.StringValue(body))
+	return record
+}
@@ -0,0 +1,204 @@
+package otelzlog
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pkgErrors "github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/pkgerrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata/conformance")
+
+// goldenOutput is what a single zerolog call produced, as stored in the golden files.
+type goldenOutput struct {
+	Severity     string            `json:"severity"`
+	SeverityText string            `json:"severity_text"`
+	Body         any               `json:"body"`
+	EventName    string            `json:"event_name,omitempty"`
+	Attributes   []goldenAttribute `json:"attributes"`
+	SpanEvents   []goldenSpanEvent `json:"span_events"`
+}
+
+type goldenAttribute struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+type goldenSpanEvent struct {
+	Name       string            `json:"name"`
+	Attributes []goldenAttribute `json:"attributes"`
+}
+
+// goldenLogValue encodes v with its kind, recursing into slices and maps.
+func goldenLogValue(v otelLog.Value) (string, any) {
+	switch v.Kind() {
+	case otelLog.KindSlice:
+		items := []any{}
+		for _, item := range v.AsSlice() {
+			kind, value := goldenLogValue(item)
+			items = append(items, map[string]any{"type": kind, "value": value})
+		}
+		return v.Kind().String(), items
+	case otelLog.KindMap:
+		return v.Kind().String(), goldenLogAttributes(v.AsMap())
+	}
+
+	return v.Kind().String(), convertLogToAny(v)
+}
+
+func goldenLogAttributes(kvs []otelLog.KeyValue) []goldenAttribute {
+	attrs := []goldenAttribute{}
+	for _, kv := range kvs {
+		kind, value := goldenLogValue(kv.Value)
+		attrs = append(attrs, goldenAttribute{Key: kv.Key, Type: kind, Value: value})
+	}
+	return attrs
+}
+
+func goldenSpanAttributes(kvs []attribute.KeyValue) []goldenAttribute {
+	attrs := []goldenAttribute{}
+	for _, kv := range kvs {
+		attrs = append(attrs, goldenAttribute{Key: string(kv.Key), Type: kv.Value.Type().String(), Value: kv.Value.AsInterface()})
+	}
+	return attrs
+}
+
+type conformanceStruct struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestConformance(t *testing.T) {
+	// fixed values so that the golden files are stable
+	now := time.Date(2025, time.June, 1, 12, 30, 45, 0, time.UTC)
+	testErr := errors.New("conformance: an error occurred")
+	stackErr := pkgErrors.New("conformance: an error occurred")
+
+	// the real stack marshaler, keeping only the first frame as the rest depends on the
+	// Go version
+	firstFrame := func(err error) any {
+		return pkgerrors.MarshalStack(err).([]map[string]string)[:1]
+	}
+
+	tests := []struct {
+		name string
+		log  func(e *zerolog.Event)
+	}{
+		{"str", func(e *zerolog.Event) { e.Str("key", "value").Msg("test log") }},
+		{"strs", func(e *zerolog.Event) { e.Strs("keys", []string{"a", "b"}).Msg("test log") }},
+		{"int", func(e *zerolog.Event) { e.Int("count", 10).Msg("test log") }},
+		{"ints", func(e *zerolog.Event) { e.Ints("counts", []int{1, 2, 3}).Msg("test log") }},
+		{"uint64", func(e *zerolog.Event) { e.Uint64("big", 1<<63).Msg("test log") }},
+		{"float", func(e *zerolog.Event) { e.Float64("ratio", 0.25).Msg("test log") }},
+		{"bool", func(e *zerolog.Event) { e.Bool("ok", true).Msg("test log") }},
+		{"bytes", func(e *zerolog.Event) { e.Bytes("data", []byte("abcd")).Msg("test log") }},
+		{"hex", func(e *zerolog.Event) { e.Hex("data", []byte{0xde, 0xad}).Msg("test log") }},
+		{"dur", func(e *zerolog.Event) { e.Dur("elapsed", 1500*time.Millisecond).Msg("test log") }},
+		{"time", func(e *zerolog.Event) { e.Time("started", now).Msg("test log") }},
+		{"err", func(e *zerolog.Event) { e.Err(testErr).Msg("test log") }},
+		{"stack", func(e *zerolog.Event) { e.Stack().Err(testErr).Msg("test log") }},
+		{"stack_pkgerrors", func(e *zerolog.Event) {
+			zerolog.ErrorStackMarshaler = firstFrame
+			e.Stack().Err(stackErr).Msg("test log")
+		}},
+		{"dict", func(e *zerolog.Event) {
+			e.Dict("http", zerolog.Dict().Str("method", "GET").Int("status", 200)).Msg("test log")
+		}},
+		{"array", func(e *zerolog.Event) {
+			e.Array("items", zerolog.Arr().Str("a").Int(1).Bool(true)).Msg("test log")
+		}},
+		{"raw_json_object", func(e *zerolog.Event) { e.RawJSON("payload", []byte(`{"b":1,"a":"x"}`)).Msg("test log") }},
+		{"raw_json_array", func(e *zerolog.Event) { e.RawJSON("payload", []byte(`[1,2]`)).Msg("test log") }},
+		{"interface", func(e *zerolog.Event) {
+			e.Interface("obj", conformanceStruct{Name: "test", Count: 2}).Msg("test log")
+		}},
+		{"ip_addr", func(e *zerolog.Event) { e.IPAddr("ip", net.ParseIP("192.168.0.1")).Msg("test log") }},
+		{"mac_addr", func(e *zerolog.Event) {
+			mac, _ := net.ParseMAC("00:1a:2b:3c:4d:5e")
+			e.MACAddr("mac", mac).Msg("test log")
+		}},
+		{"no_message", func(e *zerolog.Event) { e.Str("key", "value").Send() }},
+		{"typed_event", func(e *zerolog.Event) {
+			NewEvent(e).
+				Dur("elapsed", 1500*time.Millisecond).
+				Time("started", now).
+				Bytes("data", []byte("abcd")).
+				RawJSON("payload", []byte(`[1,2]`)).
+				Err(testErr).
+				Msg("test log")
+		}},
+	}
+
+	stackMarshaler := zerolog.ErrorStackMarshaler
+	t.Cleanup(func() { zerolog.ErrorStackMarshaler = stackMarshaler })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zerolog.ErrorStackMarshaler = func(error) any { return "stack-trace" }
+
+			rec := newRecorder(t)
+			logger := zerolog.New(io.Discard).Hook(&Hook{
+				otelLogger:      rec.Logger("test"),
+				attachSpanEvent: true,
+			})
+
+			ctx, span := rec.TracerProvider().Tracer("test").Start(t.Context(), "test.segment")
+			tt.log(logger.Info().Ctx(ctx))
+			span.End()
+
+			records := rec.Records()
+			require.Len(t, records, 1)
+			record := records[0]
+
+			var logAttrs []otelLog.KeyValue
+			record.WalkAttributes(func(kv otelLog.KeyValue) bool {
+				logAttrs = append(logAttrs, kv)
+				return true
+			})
+
+			_, body := goldenLogValue(record.Body())
+			output := goldenOutput{
+				Severity:     record.Severity().String(),
+				SeverityText: record.SeverityText(),
+				Body:         body,
+				EventName:    record.EventName(),
+				Attributes:   goldenLogAttributes(logAttrs),
+				SpanEvents:   []goldenSpanEvent{},
+			}
+			for _, event := range rec.SpanEvents("test.segment") {
+				output.SpanEvents = append(output.SpanEvents, goldenSpanEvent{
+					Name:       event.Name,
+					Attributes: goldenSpanAttributes(event.Attributes),
+				})
+			}
+
+			actual, err := json.MarshalIndent(output, "", "  ")
+			require.NoError(t, err)
+			actual = append(actual, '\n')
+
+			golden := filepath.Join("testdata", "conformance", tt.name+".golden.json")
+			if *update {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+				require.NoError(t, os.WriteFile(golden, actual, 0o644))
+			}
+
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err, "run `go test -run TestConformance -update` to create the golden file")
+			assert.JSONEq(t, string(expected), string(actual))
+		})
+	}
+}
@@ -3,10 +3,13 @@
 package otelzlog
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +19,21 @@ import (
 	"go.opentelemetry.io/otel/log"
 )
 
+// FieldType is a hint for how a zerolog field should be reconstructed after
+// being decoded from JSON. See [WithFieldTypes].
+type FieldType int
+
+const (
+	// FieldTypeDefault leaves the field as it was decoded from JSON.
+	FieldTypeDefault FieldType = iota
+	// FieldTypeDuration reconstructs a field written with `.Dur()` into a time.Duration
+	// using zerolog.DurationFieldUnit.
+	FieldTypeDuration
+	// FieldTypeTime reconstructs a field written with `.Time()` into a time.Time
+	// using zerolog.TimeFieldFormat.
+	FieldTypeTime
+)
+
 // convertLevel converts the logging level from a zerolog.Level into a an otel log.Severity
 // and the corresponding severity level string
 func convertLevel(level zerolog.Level) (log.Severity, string) {
@@ -39,6 +57,65 @@ func convertLevel(level zerolog.Level) (log.Severity, string) {
 	}
 }
 
+// convertSeverity converts an otel log.Severity into the zerolog.Level it falls within,
+// the inverse of convertLevel. An undefined severity becomes zerolog.NoLevel.
+func convertSeverity(severity log.Severity) zerolog.Level {
+	switch {
+	case severity <= log.SeverityUndefined:
+		return zerolog.NoLevel
+	case severity <= log.SeverityTrace4:
+		return zerolog.TraceLevel
+	case severity <= log.SeverityDebug4:
+		return zerolog.DebugLevel
+	case severity <= log.SeverityInfo4:
+		return zerolog.InfoLevel
+	case severity <= log.SeverityWarn4:
+		return zerolog.WarnLevel
+	case severity <= log.SeverityError4:
+		return zerolog.ErrorLevel
+	default:
+		return zerolog.FatalLevel
+	}
+}
+
+// convertSlogLevel converts a slog.Level into the zerolog.Level it falls within. Levels
+// below slog.LevelDebug are trace, and levels above slog.LevelError are still error.
+func convertSlogLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return zerolog.TraceLevel
+	case level < slog.LevelInfo:
+		return zerolog.DebugLevel
+	case level < slog.LevelWarn:
+		return zerolog.InfoLevel
+	case level < slog.LevelError:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
+
+// convertValueToField adds v to the zerolog event as the field key, with the zerolog
+// type that matches its kind. Slices and maps are added as JSON.
+func convertValueToField(e *zerolog.Event, key string, v log.Value) *zerolog.Event {
+	switch v.Kind() {
+	case log.KindBool:
+		return e.Bool(key, v.AsBool())
+	case log.KindInt64:
+		return e.Int64(key, v.AsInt64())
+	case log.KindFloat64:
+		return e.Float64(key, v.AsFloat64())
+	case log.KindString:
+		return e.Str(key, v.AsString())
+	case log.KindBytes:
+		return e.Bytes(key, v.AsBytes())
+	case log.KindSlice, log.KindMap:
+		return e.Interface(key, convertLogToAny(v))
+	}
+
+	return e.Interface(key, nil)
+}
+
 // convertAttribute converts value from `any` into the equivalent otel log.Value.
 // This function is a direct copy paste from the otelslog package.
 func convertAttribute(v any) log.Value {
@@ -120,7 +197,7 @@ func convertAttribute(v any) log.Value {
 				Value: convertAttribute(val.MapIndex(k).Interface()),
 			})
 		}
-		return log.MapValue(kvs...)
+		return log.MapValue(sortKeyValues(kvs)...)
 	case reflect.Ptr, reflect.Interface:
 		if val.IsNil() {
 			return log.Value{}
@@ -136,6 +213,97 @@ func convertAttribute(v any) log.Value {
 	return log.StringValue(fmt.Sprintf("unhandled: (%s) %+v", t, v))
 }
 
+// convertFieldType reconstructs the native value of v, as decoded from JSON, according to
+// fieldType and zerolog's formatting globals. It returns false if v could not be converted.
+func convertFieldType(v any, fieldType FieldType) (any, bool) {
+	switch fieldType {
+	case FieldTypeDuration:
+		// zerolog writes durations as a number of zerolog.DurationFieldUnit, either as an
+		// integer or a float depending on zerolog.DurationFieldInteger
+		switch n := v.(type) {
+		case float64:
+			return time.Duration(n * float64(zerolog.DurationFieldUnit)), true
+		case int64:
+			return time.Duration(n) * zerolog.DurationFieldUnit, true
+		}
+		return nil, false
+
+	case FieldTypeTime:
+		switch zerolog.TimeFieldFormat {
+		case zerolog.TimeFormatUnix, zerolog.TimeFormatUnixMs, zerolog.TimeFormatUnixMicro, zerolog.TimeFormatUnixNano:
+			// nano timestamps are beyond the integers a float64 holds exactly, so they
+			// are decoded as int64
+			switch n := v.(type) {
+			case float64:
+				return convertUnixTime(int64(n), zerolog.TimeFieldFormat), true
+			case int64:
+				return convertUnixTime(n, zerolog.TimeFieldFormat), true
+			}
+			return nil, false
+
+		default:
+			s, ok := v.(string)
+			if !ok {
+				return nil, false
+			}
+			t, err := time.Parse(zerolog.TimeFieldFormat, s)
+			if err != nil {
+				return nil, false
+			}
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
+// convertUnixTime converts a unix timestamp in the unit described by one of the zerolog
+// unix time formats into a time.Time.
+func convertUnixTime(v int64, format string) time.Time {
+	switch format {
+	case zerolog.TimeFormatUnixMs:
+		return time.UnixMilli(v)
+	case zerolog.TimeFormatUnixMicro:
+		return time.UnixMicro(v)
+	case zerolog.TimeFormatUnixNano:
+		return time.Unix(0, v)
+	default:
+		return time.Unix(v, 0)
+	}
+}
+
+// convertJSONAttribute converts a value decoded by a json.Decoder with UseNumber
+// into the equivalent otel log.Value, keeping integers as integers.
+func convertJSONAttribute(v any) log.Value {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return log.Int64Value(i)
+		}
+		if u, err := strconv.ParseUint(val.String(), 10, 64); err == nil {
+			return convertUintValue(u)
+		}
+		if f, err := val.Float64(); err == nil {
+			return log.Float64Value(f)
+		}
+		return log.StringValue(val.String())
+	case []any:
+		items := make([]log.Value, 0, len(val))
+		for _, item := range val {
+			items = append(items, convertJSONAttribute(item))
+		}
+		return log.SliceValue(items...)
+	case map[string]any:
+		kvs := make([]log.KeyValue, 0, len(val))
+		for k, item := range val {
+			kvs = append(kvs, log.KeyValue{Key: k, Value: convertJSONAttribute(item)})
+		}
+		return log.MapValue(sortKeyValues(kvs)...)
+	}
+
+	return convertAttribute(v)
+}
+
 func convertUintValue(v uint64) log.Value {
 	if v > math.MaxInt64 {
 		return log.StringValue(strconv.FormatUint(v, 10))
@@ -156,9 +324,9 @@ func convertLogToAttribute(attr log.Value) attribute.Value {
 	case log.KindBytes:
 		return attribute.StringValue(string(attr.AsBytes()))
 	case log.KindSlice:
-		return attribute.StringValue(fmt.Sprintf("%v", attr.AsSlice()))
+		return convertSliceToAttribute(attr.AsSlice())
 	case log.KindMap:
-		return attribute.StringValue(fmt.Sprintf("%v", attr.AsMap()))
+		return attribute.StringValue(marshalLogValue(attr))
 	case log.KindEmpty:
 		return attribute.StringValue("")
 	}
@@ -166,6 +334,271 @@ func convertLogToAttribute(attr log.Value) attribute.Value {
 	return attribute.StringValue(attr.AsString())
 }
 
+// convertSliceToAttribute converts a slice into the typed attribute slice of its items if
+// they all have the same primitive kind, and into its JSON encoding otherwise, as span
+// attributes can't hold heterogeneous or nested slices.
+func convertSliceToAttribute(items []log.Value) attribute.Value {
+	if len(items) > 0 && !slices.ContainsFunc(items, func(item log.Value) bool { return item.Kind() != items[0].Kind() }) {
+		switch items[0].Kind() {
+		case log.KindString:
+			return attribute.StringSliceValue(convertSlice(items, log.Value.AsString))
+		case log.KindInt64:
+			return attribute.Int64SliceValue(convertSlice(items, log.Value.AsInt64))
+		case log.KindFloat64:
+			return attribute.Float64SliceValue(convertSlice(items, log.Value.AsFloat64))
+		case log.KindBool:
+			return attribute.BoolSliceValue(convertSlice(items, log.Value.AsBool))
+		}
+	}
+
+	return attribute.StringValue(marshalLogValue(log.SliceValue(items...)))
+}
+
+func convertSlice[T any](items []log.Value, as func(log.Value) T) []T {
+	converted := make([]T, 0, len(items))
+	for _, item := range items {
+		converted = append(converted, as(item))
+	}
+	return converted
+}
+
+// marshalLogValue encodes a slice or map value as JSON, with map keys in order.
+func marshalLogValue(attr log.Value) string {
+	b, err := json.Marshal(convertLogToAny(attr))
+	if err != nil {
+		return fmt.Sprintf("%v", convertLogToAny(attr))
+	}
+	return string(b)
+}
+
+// sortKeyValues sorts map entries by key, so that maps are converted the same way every time.
+func sortKeyValues(kvs []log.KeyValue) []log.KeyValue {
+	slices.SortFunc(kvs, func(a, b log.KeyValue) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return kvs
+}
+
+// flattenAttribute expands a map attribute into one attribute per leaf, joining the
+// nested keys with dots, e.g. {"http": {"method": "GET"}} into "http.method". Slices
+// and empty maps are kept as they are.
+func flattenAttribute(kv log.KeyValue) []log.KeyValue {
+	if kv.Value.Kind() != log.KindMap || len(kv.Value.AsMap()) == 0 {
+		return []log.KeyValue{kv}
+	}
+
+	var flat []log.KeyValue
+	for _, nested := range kv.Value.AsMap() {
+		nested.Key = kv.Key + "." + nested.Key
+		flat = append(flat, flattenAttribute(nested)...)
+	}
+	return flat
+}
+
+// convertRawJSON converts a field written with `.RawJSON()`. Objects are converted from
+// their decoded value like any other field, while other JSON values are kept verbatim,
+// as bytes if asBytes is true and as a string otherwise.
+func convertRawJSON(raw json.RawMessage, decoded any, asBytes bool) log.Value {
+	if _, ok := decoded.(map[string]any); ok {
+		return convertAttribute(decoded)
+	}
+
+	if asBytes {
+		return log.BytesValue(raw)
+	}
+	return log.StringValue(string(raw))
+}
+
+// convertLogToAny converts an otel log.Value back into the equivalent go value
+// so that it can be encoded as JSON.
+func convertLogToAny(attr log.Value) any {
+	switch attr.Kind() {
+	case log.KindString:
+		return attr.AsString()
+	case log.KindFloat64:
+		return attr.AsFloat64()
+	case log.KindInt64:
+		return attr.AsInt64()
+	case log.KindBool:
+		return attr.AsBool()
+	case log.KindBytes:
+		return attr.AsBytes()
+	case log.KindSlice:
+		items := make([]any, 0, len(attr.AsSlice()))
+		for _, item := range attr.AsSlice() {
+			items = append(items, convertLogToAny(item))
+		}
+		return items
+	case log.KindMap:
+		m := make(map[string]any, len(attr.AsMap()))
+		for _, kv := range attr.AsMap() {
+			m[kv.Key] = convertLogToAny(kv.Value)
+		}
+		return m
+	}
+
+	return nil
+}
+
+// rawJSONLine adds msg to the zerolog event JSON as the message field, in the same
+// way zerolog does once the hooks have run, so that it matches the line written
+// to the writers.
+func rawJSONLine(eventJSON string, msg string) string {
+	if msg == "" {
+		return eventJSON
+	}
+
+	encodedKey, _ := json.Marshal(zerolog.MessageFieldName)
+	encodedMsg, _ := json.Marshal(msg)
+	field := string(encodedKey) + ":" + string(encodedMsg)
+
+	trimmed := strings.TrimSuffix(eventJSON, "}")
+	if strings.TrimSpace(trimmed) == "{" {
+		return "{" + field + "}"
+	}
+
+	return trimmed + "," + field + "}"
+}
+
+// fieldString returns a decoded field value as a string, encoding it as JSON if it isn't
+// one, such as the frames written by pkgerrors.MarshalStack.
+func fieldString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	b, err := marshalJSON(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// attributesJSONLine encodes the attributes as a JSON object in order, with msg added as
+// the message field in the same way as rawJSONLine.
+func attributesJSONLine(msg string, attrs []log.KeyValue) string {
+	line := []byte{'{'}
+	appendField := func(key string, value any) {
+		if len(line) > 1 {
+			line = append(line, ',')
+		}
+
+		encodedKey, _ := marshalJSON(key)
+		encodedValue, err := marshalJSON(value)
+		if err != nil {
+			// e.g. NaN and infinite floats, which zerolog writes as strings too
+			encodedValue, _ = marshalJSON(fmt.Sprint(value))
+		}
+		line = append(append(append(line, encodedKey...), ':'), encodedValue...)
+	}
+
+	for _, kv := range attrs {
+		appendField(kv.Key, convertLogToAny(kv.Value))
+	}
+	if msg != "" {
+		appendField(zerolog.MessageFieldName, msg)
+	}
+
+	return string(append(line, '}'))
+}
+
+// maxExactFloatInt is the largest integer below which every integer can be held
+// exactly by a float64.
+const maxExactFloatInt = 1 << 53
+
+// decodeEvent decodes a zerolog event JSON. Numbers are decoded as float64 like
+// json.Unmarshal does, except integers that a float64 can't hold exactly, such as
+// unix nano timestamps and large uint64s, which are kept as int64 or uint64.
+func decodeEvent(eventJSON string) (map[string]any, error) {
+	var logData map[string]any
+
+	decoder := json.NewDecoder(strings.NewReader(eventJSON))
+	decoder.UseNumber()
+	if err := decoder.Decode(&logData); err != nil {
+		return nil, err
+	}
+
+	if rest := strings.TrimLeft(eventJSON[decoder.InputOffset():], " \t\r\n"); rest != "" {
+		return nil, fmt.Errorf("invalid character %q after top-level value", rest[0])
+	}
+
+	for k, v := range logData {
+		logData[k] = normalizeNumbers(v)
+	}
+
+	return logData, nil
+}
+
+// normalizeNumbers replaces the json.Numbers in a value decoded with UseNumber as
+// described by decodeEvent.
+func normalizeNumbers(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		return normalizeNumber(val)
+	case []any:
+		for i, item := range val {
+			val[i] = normalizeNumbers(item)
+		}
+	case map[string]any:
+		for k, item := range val {
+			val[k] = normalizeNumbers(item)
+		}
+	}
+
+	return v
+}
+
+func normalizeNumber(n json.Number) any {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			if i > -maxExactFloatInt && i < maxExactFloatInt {
+				return float64(i)
+			}
+			return i
+		}
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u
+		}
+	}
+
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// decodeEventPrefix decodes the fields of a zerolog event JSON one by one, stopping at
+// the first field that is invalid. It is the fallback for events that can't be decoded
+// as a whole.
+func decodeEventPrefix(eventJSON string) map[string]any {
+	logData := map[string]any{}
+
+	decoder := json.NewDecoder(strings.NewReader(eventJSON))
+	decoder.UseNumber()
+	if tok, err := decoder.Token(); err != nil || tok != json.Delim('{') {
+		return logData
+	}
+
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			break
+		}
+
+		var v any
+		if err := decoder.Decode(&v); err != nil {
+			break
+		}
+		logData[key] = normalizeNumbers(v)
+	}
+
+	return logData
+}
+
 func extractSource(source string) (filepath string, line int, err error) {
 	colonSplit := strings.Split(source, ":")
 	if len(colonSplit) != 2 {
@@ -1,6 +1,8 @@
 package otelzlog
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -67,6 +69,60 @@ func TestConvertLevel(t *testing.T) {
 	}
 }
 
+func TestConvertSeverity(t *testing.T) {
+	tests := []struct {
+		severity log.Severity
+		expected zerolog.Level
+	}{
+		{log.SeverityUndefined, zerolog.NoLevel},
+		{log.SeverityTrace, zerolog.TraceLevel},
+		{log.SeverityTrace4, zerolog.TraceLevel},
+		{log.SeverityDebug2, zerolog.DebugLevel},
+		{log.SeverityInfo, zerolog.InfoLevel},
+		{log.SeverityInfo4, zerolog.InfoLevel},
+		{log.SeverityWarn3, zerolog.WarnLevel},
+		{log.SeverityError, zerolog.ErrorLevel},
+		{log.SeverityFatal, zerolog.FatalLevel},
+		{log.SeverityFatal4, zerolog.FatalLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.severity.String(), func(t *testing.T) {
+			assert.Equal(t, tt.expected, convertSeverity(tt.severity))
+		})
+	}
+
+	// the inverse of convertLevel, except for panic which otel doesn't distinguish from fatal
+	for _, level := range []zerolog.Level{zerolog.TraceLevel, zerolog.DebugLevel, zerolog.InfoLevel, zerolog.WarnLevel, zerolog.ErrorLevel, zerolog.FatalLevel} {
+		severity, _ := convertLevel(level)
+		assert.Equal(t, level, convertSeverity(severity))
+	}
+}
+
+func TestConvertValueToField(t *testing.T) {
+	tests := []struct {
+		input    log.Value
+		expected string
+	}{
+		{log.BoolValue(true), `{"key":true}`},
+		{log.Int64Value(10), `{"key":10}`},
+		{log.Float64Value(0.5), `{"key":0.5}`},
+		{log.StringValue("value"), `{"key":"value"}`},
+		{log.BytesValue([]byte("abcd")), `{"key":"abcd"}`},
+		{log.SliceValue(log.Int64Value(1), log.StringValue("a")), `{"key":[1,"a"]}`},
+		{log.MapValue(log.Int64("a", 1)), `{"key":{"a":1}}`},
+		{log.Value{}, `{"key":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			logger := zerolog.New(buf)
+
+			convertValueToField(logger.Log(), "key", tt.input).Send()
+			assert.JSONEq(t, tt.expected, buf.String())
+		})
+	}
+}
+
 func TestConvertAttribute(t *testing.T) {
 	now := time.Now()
 
@@ -217,6 +273,129 @@ func TestConvertAttribute(t *testing.T) {
 	}
 }
 
+func TestConvertFieldType(t *testing.T) {
+	defer func(unit time.Duration, integer bool, format string) {
+		zerolog.DurationFieldUnit = unit
+		zerolog.DurationFieldInteger = integer
+		zerolog.TimeFieldFormat = format
+	}(zerolog.DurationFieldUnit, zerolog.DurationFieldInteger, zerolog.TimeFieldFormat)
+
+	now := time.Unix(1700000000, 0)
+	nowNano := time.Unix(1700000000, 123456789)
+
+	tests := []struct {
+		name      string
+		unit      time.Duration
+		integer   bool
+		format    string
+		fieldType FieldType
+		input     any
+		expected  any
+		ok        bool
+	}{
+		{
+			name:      "duration ms float",
+			unit:      time.Millisecond,
+			fieldType: FieldTypeDuration,
+			input:     float64(1500.5),
+			expected:  1500*time.Millisecond + 500*time.Microsecond,
+			ok:        true,
+		},
+		{
+			name:      "duration s integer",
+			unit:      time.Second,
+			integer:   true,
+			fieldType: FieldTypeDuration,
+			input:     float64(3),
+			expected:  3 * time.Second,
+			ok:        true,
+		},
+		{
+			name:      "duration not a number",
+			unit:      time.Millisecond,
+			fieldType: FieldTypeDuration,
+			input:     "3s",
+			ok:        false,
+		},
+		{
+			name:      "time rfc3339",
+			format:    time.RFC3339,
+			fieldType: FieldTypeTime,
+			input:     now.UTC().Format(time.RFC3339),
+			expected:  now.UTC(),
+			ok:        true,
+		},
+		{
+			name:      "time unix",
+			format:    zerolog.TimeFormatUnix,
+			fieldType: FieldTypeTime,
+			input:     float64(now.Unix()),
+			expected:  now,
+			ok:        true,
+		},
+		{
+			name:      "time unix ms",
+			format:    zerolog.TimeFormatUnixMs,
+			fieldType: FieldTypeTime,
+			input:     float64(now.UnixMilli()),
+			expected:  now,
+			ok:        true,
+		},
+		{
+			name:      "time unix micro",
+			format:    zerolog.TimeFormatUnixMicro,
+			fieldType: FieldTypeTime,
+			input:     nowNano.UnixMicro(),
+			expected:  nowNano.Truncate(time.Microsecond),
+			ok:        true,
+		},
+		{
+			name:      "time unix nano",
+			format:    zerolog.TimeFormatUnixNano,
+			fieldType: FieldTypeTime,
+			input:     nowNano.UnixNano(),
+			expected:  nowNano,
+			ok:        true,
+		},
+		{
+			name:      "duration ns integer",
+			unit:      time.Nanosecond,
+			integer:   true,
+			fieldType: FieldTypeDuration,
+			input:     int64(1<<53 + 1),
+			expected:  time.Duration(1<<53 + 1),
+			ok:        true,
+		},
+		{
+			name:      "time unparsable",
+			format:    time.RFC3339,
+			fieldType: FieldTypeTime,
+			input:     "yesterday",
+			ok:        false,
+		},
+		{
+			name:      "default",
+			fieldType: FieldTypeDefault,
+			input:     float64(10),
+			ok:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zerolog.DurationFieldUnit = tt.unit
+			zerolog.DurationFieldInteger = tt.integer
+			zerolog.TimeFieldFormat = tt.format
+
+			out, ok := convertFieldType(tt.input, tt.fieldType)
+			require.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.expected, out)
+			}
+		})
+	}
+}
+
 func TestConvertUintValue(t *testing.T) {
 	for range 100 {
 		in := rand.Uint64()
@@ -266,15 +445,39 @@ func TestConvertLogToAttribute(t *testing.T) {
 				log.Int64Value(2),
 				log.Int64Value(3),
 			),
-			expected: attribute.StringValue("[1 2 3]"),
+			expected: attribute.Int64SliceValue([]int64{1, 2, 3}),
+		},
+		{
+			input:    log.SliceValue(log.StringValue("a"), log.StringValue("b")),
+			expected: attribute.StringSliceValue([]string{"a", "b"}),
+		},
+		{
+			input:    log.SliceValue(log.Float64Value(1.5), log.Float64Value(2.5)),
+			expected: attribute.Float64SliceValue([]float64{1.5, 2.5}),
+		},
+		{
+			input:    log.SliceValue(log.BoolValue(true), log.BoolValue(false)),
+			expected: attribute.BoolSliceValue([]bool{true, false}),
+		},
+		{
+			input:    log.SliceValue(log.Int64Value(1), log.StringValue("a")),
+			expected: attribute.StringValue(`[1,"a"]`),
+		},
+		{
+			input:    log.SliceValue(log.MapValue(log.String("a", "b"))),
+			expected: attribute.StringValue(`[{"a":"b"}]`),
+		},
+		{
+			input:    log.SliceValue(),
+			expected: attribute.StringValue(`[]`),
 		},
 		{
 			input: log.MapValue(
-				log.Int64("a", 1),
-				log.Int64("b", 2),
 				log.Int64("c", 3),
+				log.Int64("a", 1),
+				log.Map("b", log.String("d", "e")),
 			),
-			expected: attribute.StringValue("[a:1 b:2 c:3]"),
+			expected: attribute.StringValue(`{"a":1,"b":{"d":"e"},"c":3}`),
 		},
 	}
 	for _, tt := range tests {
@@ -321,3 +524,145 @@ func TestExtractSource(t *testing.T) {
 		})
 	}
 }
+
+func TestRawJSONLine(t *testing.T) {
+	tests := []struct {
+		event    string
+		msg      string
+		expected string
+	}{
+		{
+			event:    `{"level":"info"}`,
+			msg:      "test message",
+			expected: `{"level":"info","message":"test message"}`,
+		},
+		{
+			event:    `{"level":"info"}`,
+			msg:      "",
+			expected: `{"level":"info"}`,
+		},
+		{
+			event:    `{}`,
+			msg:      `a "quoted" message`,
+			expected: `{"message":"a \"quoted\" message"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rawJSONLine(tt.event, tt.msg))
+		})
+	}
+}
+
+func TestDecodeEventPrefix(t *testing.T) {
+	tests := []struct {
+		event    string
+		expected map[string]any
+	}{
+		{
+			event:    `{"level":"info","a":"1","raw":{invalid,"b":"2"}`,
+			expected: map[string]any{"level": "info", "a": "1"},
+		},
+		{
+			event:    `{"level":"info","a":{"b":[1,2]}}`,
+			expected: map[string]any{"level": "info", "a": map[string]any{"b": []any{1.0, 2.0}}},
+		},
+		{
+			event:    `{"level":"info",`,
+			expected: map[string]any{"level": "info"},
+		},
+		{
+			event:    `invalid`,
+			expected: map[string]any{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			assert.Equal(t, tt.expected, decodeEventPrefix(tt.event))
+		})
+	}
+}
+
+func TestConvertAttributeMapOrder(t *testing.T) {
+	input := map[string]any{"c": 3, "a": 1, "b": map[string]any{"e": true, "d": false}}
+	expected := log.MapValue(
+		log.Int64("a", 1),
+		log.Map("b", log.Bool("d", false), log.Bool("e", true)),
+		log.Int64("c", 3),
+	)
+
+	for range 10 {
+		assert.Equal(t, expected, convertAttribute(input))
+		assert.Equal(t, expected, convertJSONAttribute(input))
+	}
+}
+
+func TestFlattenAttribute(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    log.KeyValue
+		expected []log.KeyValue
+	}{
+		{
+			name:     "scalar",
+			input:    log.String("a", "b"),
+			expected: []log.KeyValue{log.String("a", "b")},
+		},
+		{
+			name: "nested",
+			input: log.Map("http",
+				log.String("method", "GET"),
+				log.Map("response", log.Int64("status", 200)),
+				log.Slice("tags", log.StringValue("a")),
+			),
+			expected: []log.KeyValue{
+				log.String("http.method", "GET"),
+				log.Int64("http.response.status", 200),
+				log.Slice("http.tags", log.StringValue("a")),
+			},
+		},
+		{
+			name:     "empty map",
+			input:    log.Map("http"),
+			expected: []log.KeyValue{log.Map("http")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, flattenAttribute(tt.input))
+		})
+	}
+}
+
+func TestConvertRawJSON(t *testing.T) {
+	tests := []struct {
+		raw      string
+		asBytes  bool
+		expected log.Value
+	}{
+		{
+			raw:      `{"b":1,"a":"x"}`,
+			expected: log.MapValue(log.String("a", "x"), log.Float64("b", 1)),
+		},
+		{
+			raw:      `[1, 2]`,
+			expected: log.StringValue(`[1, 2]`),
+		},
+		{
+			raw:      `"quoted"`,
+			expected: log.StringValue(`"quoted"`),
+		},
+		{
+			raw:      `[1, 2]`,
+			asBytes:  true,
+			expected: log.BytesValue([]byte(`[1, 2]`)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			var decoded any
+			require.NoError(t, json.Unmarshal([]byte(tt.raw), &decoded))
+			assert.Equal(t, tt.expected, convertRawJSON(json.RawMessage(tt.raw), decoded, tt.asBytes))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package otelzlog dedup holds the deduplicator that suppresses repeated log
+// records before they are exported
+package otelzlog
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	otelLog "go.opentelemetry.io/otel/log"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// suppressedCountKey is the attribute of the summary record that holds the number of
+// records suppressed during the window.
+const suppressedCountKey = "log.suppressed_count"
+
+// dedupKey identifies repeated records by message, level and caller.
+type dedupKey struct {
+	msg    string
+	level  zerolog.Level
+	caller string
+}
+
+// dedupEntry tracks the records suppressed for a key during the current window.
+type dedupEntry struct {
+	ctx        context.Context
+	record     otelLog.Record
+	suppressed int
+}
+
+// deduplicator lets the first record for each key through, then suppresses the
+// duplicates until the window ends, at which point a single summary record is emitted.
+type deduplicator struct {
+	window time.Duration
+	emit   func(ctx context.Context, record otelLog.Record)
+
+	mu      sync.Mutex
+	entries map[dedupKey]*dedupEntry
+}
+
+func newDeduplicator(window time.Duration, emit func(ctx context.Context, record otelLog.Record)) *deduplicator {
+	return &deduplicator{
+		window:  window,
+		emit:    emit,
+		entries: map[dedupKey]*dedupEntry{},
+	}
+}
+
+// allow reports whether the record should be emitted. Records that are not allowed are
+// counted, and the latest of them is kept for the summary.
+func (d *deduplicator) allow(ctx context.Context, key dedupKey, record otelLog.Record) bool {
+	if d == nil {
+		return true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if entry, ok := d.entries[key]; ok {
+		entry.ctx = context.WithoutCancel(ctx)
+		entry.record = record
+		entry.suppressed++
+		return false
+	}
+
+	d.entries[key] = &dedupEntry{}
+	time.AfterFunc(d.window, func() { d.flush(key) })
+
+	return true
+}
+
+// flush ends the window for key and emits the summary record if any duplicates were suppressed.
+func (d *deduplicator) flush(key dedupKey) {
+	d.mu.Lock()
+	entry := d.entries[key]
+	delete(d.entries, key)
+	d.mu.Unlock()
+
+	if entry == nil || entry.suppressed == 0 {
+		return
+	}
+
+	record := entry.record
+	record.AddAttributes(otelLog.Int(suppressedCountKey, entry.suppressed))
+	d.emit(entry.ctx, record)
+}
+
+// dedupKeyFor builds the key for a record from its message, level and source
+// location, if source is enabled.
+func dedupKeyFor(msg string, level zerolog.Level, logAttributes []otelLog.KeyValue) dedupKey {
+	var file, line string
+	for _, kv := range logAttributes {
+		switch kv.Key {
+		case string(semconv.CodeFilepathKey):
+			file = kv.Value.String()
+		case string(semconv.CodeLineNumberKey):
+			line = kv.Value.String()
+		}
+	}
+
+	return dedupKey{msg: msg, level: level, caller: file + ":" + line}
+}
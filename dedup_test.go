@@ -0,0 +1,65 @@
+package otelzlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+func TestDedupKeyFor(t *testing.T) {
+	key := dedupKeyFor("test log", zerolog.InfoLevel, []otelLog.KeyValue{
+		otelLog.String("code.filepath", "/path/to/main.go"),
+		otelLog.Int("code.lineno", 17),
+	})
+	assert.Equal(t, dedupKey{msg: "test log", level: zerolog.InfoLevel, caller: "/path/to/main.go:17"}, key)
+
+	key = dedupKeyFor("test log", zerolog.InfoLevel, nil)
+	assert.Equal(t, dedupKey{msg: "test log", level: zerolog.InfoLevel, caller: ":"}, key)
+}
+
+func TestHookDeduplication(t *testing.T) {
+	recorder := newRecorder(t)
+	buf := new(bytes.Buffer)
+
+	hook := &Hook{otelLogger: recorder.Logger("test")}
+	hook.dedup = newDeduplicator(50*time.Millisecond, hook.emit)
+	logger := zerolog.New(buf).Hook(hook)
+
+	for i := range 5 {
+		logger.Error().Int("attempt", i).Msg("connection refused")
+	}
+	logger.Warn().Msg("connection refused")
+
+	require.Len(t, recorder.Records(), 2)
+	assert.Equal(t, 6, strings.Count(buf.String(), "\n"))
+
+	require.Eventually(t, func() bool { return len(recorder.Records()) == 3 }, time.Second, 10*time.Millisecond)
+
+	summary := recorder.Records()[2]
+	assert.Equal(t, "connection refused", summary.Body().AsString())
+	assert.Equal(t, otelLog.SeverityError, summary.Severity())
+	attrs := summary.Attributes()
+	assert.Equal(t, otelLog.Int64Value(4), attrs[suppressedCountKey])
+	assert.Equal(t, otelLog.Float64Value(4), attrs["attempt"])
+
+	// a new window starts once the previous one has ended
+	logger.Error().Msg("connection refused")
+	assert.Len(t, recorder.Records(), 4)
+}
+
+func TestDeduplicatorNoDuplicates(t *testing.T) {
+	recorder := newRecorder(t)
+	d := newDeduplicator(time.Millisecond, recorder.Logger("test").Emit)
+
+	assert.True(t, d.allow(t.Context(), dedupKey{msg: "test log"}, otelLog.Record{}))
+	time.Sleep(20 * time.Millisecond)
+
+	assert.Empty(t, recorder.Records())
+	assert.True(t, d.allow(t.Context(), dedupKey{msg: "test log"}, otelLog.Record{}))
+}
@@ -0,0 +1,138 @@
+// Package otelzlog diagnostics holds the error handling and the counters that
+// report on the health of the hook without logging through it
+package otelzlog
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+// Stats holds the self-diagnostics counters of a [Hook].
+type Stats struct {
+	// DecodeFailures is the number of events whose zerolog buffer could not be decoded.
+	DecodeFailures uint64
+	// DroppedAttributes is the number of attributes, slice elements and map entries
+	// dropped by the attribute limits, on both log records and span events.
+	DroppedAttributes uint64
+	// TruncatedValues is the number of string and byte values cut by the maximum value
+	// length, and of values replaced beyond the maximum depth, on both log records and
+	// span events.
+	TruncatedValues uint64
+	// EmitErrors is the number of records whose emit panicked, and of failed flushes
+	// of the pipeline. The otel logger doesn't return errors from Emit, so records
+	// the exporter fails to send later on are not counted here.
+	EmitErrors uint64
+	// DroppedRecords is the number of records dropped by the queue of an asynchronous [Hook].
+	DroppedRecords uint64
+	// DiscardedErrors is the number of errors that weren't passed to the error handler
+	// because they were raised by the handler itself, e.g. while it logged through the
+	// hooked logger.
+	DiscardedErrors uint64
+}
+
+// diagnostics holds the counters behind [Stats].
+type diagnostics struct {
+	decodeFailures    atomic.Uint64
+	droppedAttributes atomic.Uint64
+	truncatedValues   atomic.Uint64
+	emitErrors        atomic.Uint64
+	discardedErrors   atomic.Uint64
+}
+
+// recordLimits adds what the attribute limits dropped and truncated to the counters.
+func (d *diagnostics) recordLimits(counts limitCounts) {
+	d.droppedAttributes.Add(uint64(counts.dropped))
+	d.truncatedValues.Add(uint64(counts.truncated))
+}
+
+// emittingKey is the context key that marks the context passed to the otel logger.
+type emittingKey struct{}
+
+// isEmitting reports whether ctx was passed to the otel logger by the [Hook], meaning
+// that the event was logged from within the otel pipeline.
+func isEmitting(ctx context.Context) bool {
+	return ctx.Value(emittingKey{}) != nil
+}
+
+// handleError passes err to the error handler, or to otel.Handle if none is
+// configured. Errors raised from within the handler, such as by a handler that logs
+// through the hooked logger, are discarded instead of recursing, and counted in [Stats].
+// Errors raised on other goroutines while the handler runs are still handled.
+func (h *Hook) handleError(err error) {
+	if isHandlingError() {
+		h.diag.discardedErrors.Add(1)
+		return
+	}
+
+	if h.errorHandler != nil {
+		h.errorHandler(err)
+		return
+	}
+
+	otel.Handle(err)
+}
+
+// maxHandlerDepth is the number of frames searched for a running error handler, which
+// is well past the frames of a handler logging through the hooked logger.
+const maxHandlerDepth = 256
+
+// handleErrorFunc is the name reported in stack traces for [Hook.handleError].
+const handleErrorFunc = "github.com/adreasnow/otelzlog.(*Hook).handleError"
+
+// isHandlingError reports whether the calling goroutine is already running the error
+// handler, by looking for handleError further up its own stack. Goroutines can't be
+// marked directly, and the handler has no context to mark.
+func isHandlingError() bool {
+	pcs := make([]uintptr, maxHandlerDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if frame.Function == handleErrorFunc {
+			return true
+		}
+		if !more {
+			return false
+		}
+	}
+}
+
+// exportRecord emits the record to the otel logger. A panicking logger is reported as
+// an emit error instead of crashing the application.
+func (h *Hook) exportRecord(ctx context.Context, record otelLog.Record) {
+	defer func() {
+		if r := recover(); r != nil {
+			h.diag.emitErrors.Add(1)
+			h.handleError(fmt.Errorf("otelzlog: the otel logger panicked while emitting a record: %v", r))
+		}
+	}()
+
+	h.otelLogger.Emit(context.WithValue(ctx, emittingKey{}, true), record)
+}
+
+// Stats returns a snapshot of the self-diagnostics counters of the [Hook].
+func (h *Hook) Stats() Stats {
+	return Stats{
+		DecodeFailures:    h.diag.decodeFailures.Load(),
+		DroppedAttributes: h.diag.droppedAttributes.Load(),
+		TruncatedValues:   h.diag.truncatedValues.Load(),
+		EmitErrors:        h.diag.emitErrors.Load(),
+		DroppedRecords:    h.DroppedRecords(),
+		DiscardedErrors:   h.diag.discardedErrors.Load(),
+	}
+}
+
+// HookStats returns a snapshot of the self-diagnostics counters of the [Hook] created
+// by [New] for ctx.
+func HookStats(ctx context.Context) Stats {
+	hook := hookFromContext(ctx)
+	if hook == nil {
+		return Stats{}
+	}
+
+	return hook.Stats()
+}
@@ -0,0 +1,144 @@
+package otelzlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+// loggingLogger is an otel logger that logs through zerolog while emitting, like an
+// exporter reporting its own progress through the hooked logger.
+type loggingLogger struct {
+	otelLog.Logger
+	logger *zerolog.Logger
+}
+
+func (l *loggingLogger) Emit(ctx context.Context, record otelLog.Record) {
+	l.logger.Info().Ctx(ctx).Msg("exporting record")
+	l.Logger.Emit(ctx, record)
+}
+
+// panickingLogger is an otel logger that panics on every emit.
+type panickingLogger struct {
+	otelLog.Logger
+}
+
+func (l *panickingLogger) Emit(context.Context, otelLog.Record) {
+	panic("exporter is closed")
+}
+
+func TestHookDecodeFailure(t *testing.T) {
+	recorder := newRecorder(t)
+	var errs []error
+
+	hook := &Hook{otelLogger: recorder.Logger("test")}
+	logger := zerolog.New(new(bytes.Buffer)).Hook(hook)
+
+	// the handler logs through the hooked logger, which must not recurse
+	hook.errorHandler = func(err error) {
+		errs = append(errs, err)
+		logger.Error().RawJSON("raw", []byte("{invalid")).Msg("still invalid")
+	}
+
+	logger.Info().RawJSON("raw", []byte("{invalid")).Msg("test log")
+
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], `could not unmarshal the info event "test log"'s attribute buffer`)
+	assert.Equal(t, uint64(2), hook.Stats().DecodeFailures)
+	assert.Equal(t, uint64(1), hook.Stats().DiscardedErrors)
+	assert.Len(t, recorder.Records(), 2)
+}
+
+func TestHookConcurrentErrors(t *testing.T) {
+	handling := make(chan struct{})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var errs []error
+
+	hook := &Hook{errorHandler: func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+
+		if err.Error() == "diagnostics: first error" {
+			close(handling)
+			<-release
+		}
+	}}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hook.handleError(errors.New("diagnostics: first error"))
+	}()
+
+	// an error raised on another goroutine while the handler runs is still handled
+	<-handling
+	hook.handleError(errors.New("diagnostics: second error"))
+	close(release)
+	<-done
+
+	require.Len(t, errs, 2)
+	assert.ErrorContains(t, errs[0], "first error")
+	assert.ErrorContains(t, errs[1], "second error")
+	assert.Equal(t, uint64(0), hook.Stats().DiscardedErrors)
+}
+
+func TestHookEmitReentry(t *testing.T) {
+	buf := new(bytes.Buffer)
+	recorder := newRecorder(t)
+	otelLogger := &loggingLogger{Logger: recorder.Logger("test")}
+
+	hook := &Hook{otelLogger: otelLogger}
+	logger := zerolog.New(buf).Hook(hook)
+	otelLogger.logger = &logger
+
+	logger.Info().Msg("test log")
+
+	require.Len(t, recorder.Records(), 1)
+	assert.Equal(t, "test log", recorder.Records()[0].Body().AsString())
+	assert.Contains(t, buf.String(), "exporting record", "the event must still reach the writers")
+}
+
+func TestHookEmitError(t *testing.T) {
+	var errs []error
+
+	hook := &Hook{otelLogger: &panickingLogger{}, errorHandler: func(err error) { errs = append(errs, err) }}
+	logger := zerolog.New(new(bytes.Buffer)).Hook(hook)
+
+	assert.NotPanics(t, func() { logger.Info().Msg("test log") })
+
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "exporter is closed")
+	assert.Equal(t, uint64(1), hook.Stats().EmitErrors)
+}
+
+func TestHookDroppedAttributesStats(t *testing.T) {
+	hook := &Hook{
+		otelLogger:      newRecorder(t).Logger("test"),
+		attachSpanEvent: true,
+		logLimits:       attributeLimits{maxCount: 1, maxValueLen: 16},
+		spanLimits:      attributeLimits{maxCount: 2},
+	}
+	logger := zerolog.New(new(bytes.Buffer)).Hook(hook)
+
+	logger.Info().Str("a", "abcdefghijklmnopqrstuvwxyz").Str("b", "2").Str("c", "3").Msg("test log")
+
+	// "a", "b", "c" and "level" are limited to 1 on the record and 2 on the span event,
+	// and "a" is truncated on the record only
+	assert.Equal(t, Stats{DroppedAttributes: 5, TruncatedValues: 1}, hook.Stats())
+}
+
+func TestHookStats(t *testing.T) {
+	assert.Equal(t, Stats{}, HookStats(t.Context()))
+
+	ctx := New(t.Context(), "test", WithLoggerProvider(newRecorder(t)))
+	assert.Equal(t, Stats{}, HookStats(ctx))
+}
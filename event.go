@@ -0,0 +1,221 @@
+// Package otelzlog event holds a wrapper around the zerolog event that keeps
+// the native types of fields for the otel log record
+package otelzlog
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// typedFieldsKey is the context key under which an [Event] stores its typed fields.
+type typedFieldsKey struct{}
+
+// typedFields holds the native values of the fields written through an [Event].
+type typedFields struct {
+	values map[string]any
+
+	// omitCaller stops the [callerHook] from adding a caller to an event that has none.
+	omitCaller bool
+}
+
+// typedFieldsFromContext returns the typed fields stored in ctx by an [Event], or nil
+// if the event was not created through an [Event].
+func typedFieldsFromContext(ctx context.Context) map[string]any {
+	fields, ok := ctx.Value(typedFieldsKey{}).(*typedFields)
+	if !ok {
+		return nil
+	}
+
+	return fields.values
+}
+
+// Event wraps a `*zerolog.Event` and records the native value of each field
+// alongside the zerolog JSON encoding, so that the [Hook] can send durations,
+// times and byte slices to otel without losing their types in a JSON round-trip.
+//
+// Like `*zerolog.Event`, an Event must not be reused after Msg, Msgf or Send is called.
+type Event struct {
+	event  *zerolog.Event
+	fields *typedFields
+}
+
+// NewEvent wraps a `*zerolog.Event`, e.g.
+//
+//	otelzlog.NewEvent(log.Ctx(ctx).Info().Ctx(ctx)).Dur("elapsed", d).Msg("done")
+func NewEvent(e *zerolog.Event) *Event {
+	ev := &Event{
+		event:  e,
+		fields: &typedFields{values: map[string]any{}},
+	}
+
+	return ev.Ctx(e.GetCtx())
+}
+
+// Ctx sets the context of the underlying `*zerolog.Event`. It must be used instead of
+// calling `.Ctx()` on the zerolog event directly, otherwise the typed fields are lost.
+func (e *Event) Ctx(ctx context.Context) *Event {
+	if !e.event.Enabled() {
+		return e
+	}
+
+	e.event.Ctx(context.WithValue(ctx, typedFieldsKey{}, e.fields))
+	return e
+}
+
+// Str adds the field key with val as a string.
+func (e *Event) Str(key, val string) *Event {
+	e.event.Str(key, val)
+	e.fields.values[key] = val
+	return e
+}
+
+// Int adds the field key with i as an int.
+func (e *Event) Int(key string, i int) *Event {
+	e.event.Int(key, i)
+	e.fields.values[key] = i
+	return e
+}
+
+// Int64 adds the field key with i as an int64.
+func (e *Event) Int64(key string, i int64) *Event {
+	e.event.Int64(key, i)
+	e.fields.values[key] = i
+	return e
+}
+
+// Uint64 adds the field key with i as a uint64.
+func (e *Event) Uint64(key string, i uint64) *Event {
+	e.event.Uint64(key, i)
+	e.fields.values[key] = i
+	return e
+}
+
+// Float64 adds the field key with f as a float64.
+func (e *Event) Float64(key string, f float64) *Event {
+	e.event.Float64(key, f)
+	e.fields.values[key] = f
+	return e
+}
+
+// Bool adds the field key with b as a bool.
+func (e *Event) Bool(key string, b bool) *Event {
+	e.event.Bool(key, b)
+	e.fields.values[key] = b
+	return e
+}
+
+// Dur adds the field key with d as a duration. The otel attribute holds
+// the duration in nanoseconds, regardless of zerolog.DurationFieldUnit.
+func (e *Event) Dur(key string, d time.Duration) *Event {
+	e.event.Dur(key, d)
+	e.fields.values[key] = d
+	return e
+}
+
+// Time adds the field key with t as a timestamp. The otel attribute holds
+// the time in unix nanoseconds, regardless of zerolog.TimeFieldFormat.
+func (e *Event) Time(key string, t time.Time) *Event {
+	e.event.Time(key, t)
+	e.fields.values[key] = t
+	return e
+}
+
+// Bytes adds the field key with b as a byte slice. The otel attribute holds
+// the raw bytes rather than the string zerolog writes.
+func (e *Event) Bytes(key string, b []byte) *Event {
+	e.event.Bytes(key, b)
+	e.fields.values[key] = b
+	return e
+}
+
+// RawJSON adds the field key with b as already encoded JSON. JSON objects are
+// converted to map attributes like any other field, while other JSON values are
+// kept verbatim instead of being decoded. See [WithRawJSONBytes].
+func (e *Event) RawJSON(key string, b []byte) *Event {
+	e.event.RawJSON(key, b)
+	e.fields.values[key] = json.RawMessage(b)
+	return e
+}
+
+// Interface adds the field key with i marshaled as JSON. The field takes the usual
+// JSON path in the [Hook], so objects become map attributes.
+func (e *Event) Interface(key string, i any) *Event {
+	e.event.Interface(key, i)
+	return e
+}
+
+// Caller adds the caller field with the file and line of the call site, like zerolog's
+// `.Caller()`. The logger created by [New] doesn't add its own caller field to the event.
+func (e *Event) Caller(file string, line int) *Event {
+	caller := file + ":" + strconv.Itoa(line)
+	e.event.Str(zerolog.CallerFieldName, caller)
+	e.fields.values[zerolog.CallerFieldName] = caller
+	return e
+}
+
+// omitCaller stops the logger from adding its own caller field, for events whose call
+// site isn't known.
+func (e *Event) omitCaller() *Event {
+	e.fields.omitCaller = true
+	return e
+}
+
+// Err adds the field "error" with err. The error is kept as-is so that the
+// [Hook] can inspect it as an error instead of as its message.
+func (e *Event) Err(err error) *Event {
+	e.event.Err(err)
+	if err != nil {
+		e.fields.values[zerolog.ErrorFieldName] = err
+	}
+	return e
+}
+
+// Zerolog returns the underlying `*zerolog.Event` so that fields without a typed
+// counterpart can still be added. Those fields take the usual JSON path in the [Hook].
+func (e *Event) Zerolog() *zerolog.Event {
+	return e.event
+}
+
+// Msg sends the event with msg added as the message field if not empty.
+func (e *Event) Msg(msg string) {
+	// skip this frame, so that the caller added by the logger is the call site
+	e.event.CallerSkipFrame(1).Msg(msg)
+}
+
+// Msgf sends the event with a formatted msg added as the message field if not empty.
+func (e *Event) Msgf(format string, v ...any) {
+	e.event.CallerSkipFrame(1).Msgf(format, v...)
+}
+
+// Send is equivalent to calling Msg("").
+func (e *Event) Send() {
+	e.event.CallerSkipFrame(1).Send()
+}
+
+// callerHookSkipFrameCount is the number of frames that `zerolog.Event.Caller` must skip
+// in a hook to reach the call site: zerolog's two caller methods, the hook, and zerolog's
+// event send and message methods.
+const callerHookSkipFrameCount = 5
+
+// callerHook adds the caller field like `zerolog.Context.CallerWithSkipFrameCount`, except
+// for events that set their own with [Event.Caller], or that have none, so that the line
+// written by zerolog holds a single caller field.
+type callerHook struct {
+	skip int
+}
+
+// Run adds the caller field of the call site to e.
+func (h callerHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if fields, ok := e.GetCtx().Value(typedFieldsKey{}).(*typedFields); ok {
+		if _, typed := fields.values[zerolog.CallerFieldName]; typed || fields.omitCaller {
+			return
+		}
+	}
+
+	// zerolog.Event.Caller adds zerolog.CallerSkipFrameCount itself.
+	e.Caller(h.skip + callerHookSkipFrameCount - zerolog.CallerSkipFrameCount)
+}
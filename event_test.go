@@ -0,0 +1,151 @@
+package otelzlog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+func TestEvent(t *testing.T) {
+	t.Run("typed fields", func(t *testing.T) {
+		recorder := newRecorder(t)
+		logger := zerolog.New(io.Discard).Hook(&Hook{otelLogger: recorder.Logger("test")})
+
+		now := time.Now()
+		testErr := errors.New("event: an error occurred")
+
+		NewEvent(logger.Info().Ctx(t.Context())).
+			Str("str", "value").
+			Int("int", 10).
+			Dur("dur", time.Second).
+			Time("time", now).
+			Bytes("bytes", []byte("abcd")).
+			Err(testErr).
+			Msg("test message")
+
+		records := recorder.Records()
+		require.Len(t, records, 1)
+		attrs := records[0].Attributes()
+
+		assert.Equal(t, otelLog.StringValue("value"), attrs["str"])
+		assert.Equal(t, otelLog.Int64Value(10), attrs["int"])
+		assert.Equal(t, otelLog.Int64Value(time.Second.Nanoseconds()), attrs["dur"])
+		assert.Equal(t, otelLog.Int64Value(now.UnixNano()), attrs["time"])
+		assert.Equal(t, otelLog.BytesValue([]byte("abcd")), attrs["bytes"])
+		assert.Equal(t, otelLog.StringValue(testErr.Error()), attrs["exception.message"])
+	})
+
+	t.Run("untyped fields", func(t *testing.T) {
+		recorder := newRecorder(t)
+		logger := zerolog.New(io.Discard).Hook(&Hook{otelLogger: recorder.Logger("test")})
+
+		NewEvent(logger.Info()).Zerolog().
+			Dur("dur", time.Second).
+			Msg("test message")
+
+		records := recorder.Records()
+		require.Len(t, records, 1)
+		assert.Equal(t, otelLog.Float64Value(1000), records[0].Attributes()["dur"])
+	})
+
+	t.Run("numbers and bools", func(t *testing.T) {
+		recorder := newRecorder(t)
+		logger := zerolog.New(io.Discard).Hook(&Hook{otelLogger: recorder.Logger("test")})
+
+		NewEvent(logger.Info().Ctx(t.Context())).
+			Int64("int64", 10).
+			Uint64("uint64", 20).
+			Float64("float64", 0.5).
+			Bool("bool", true).
+			Interface("obj", map[string]int{"a": 1}).
+			Msg("test message")
+
+		records := recorder.Records()
+		require.Len(t, records, 1)
+		attrs := records[0].Attributes()
+
+		assert.Equal(t, otelLog.Int64Value(10), attrs["int64"])
+		assert.Equal(t, otelLog.Int64Value(20), attrs["uint64"])
+		assert.Equal(t, otelLog.Float64Value(0.5), attrs["float64"])
+		assert.Equal(t, otelLog.BoolValue(true), attrs["bool"])
+		assert.Equal(t, otelLog.MapValue(otelLog.Float64("a", 1)), attrs["obj"])
+	})
+
+	t.Run("caller", func(t *testing.T) {
+		recorder := newRecorder(t)
+		logger := zerolog.New(io.Discard).With().Caller().Logger().Hook(&Hook{otelLogger: recorder.Logger("test"), source: true})
+
+		NewEvent(logger.Info().Ctx(t.Context())).
+			Caller("/path/to/main.go", 17).
+			Msg("test message")
+
+		records := recorder.Records()
+		require.Len(t, records, 1)
+		attrs := records[0].Attributes()
+
+		assert.Equal(t, otelLog.StringValue("/path/to/main.go"), attrs["code.filepath"])
+		assert.Equal(t, otelLog.Int64Value(17), attrs["code.lineno"])
+	})
+
+	t.Run("source", func(t *testing.T) {
+		recorder := newRecorder(t)
+		buf := new(bytes.Buffer)
+		ctx := New(t.Context(), "test", WithLoggerProvider(recorder), WithWriter(buf), WithSource(true, 0))
+		logger := zerolog.Ctx(ctx)
+
+		_, file, line, _ := runtime.Caller(0)
+		NewEvent(logger.Info().Ctx(ctx)).Msg("test message")
+		NewEvent(logger.Info().Ctx(ctx)).Msgf("test %s", "message")
+		NewEvent(logger.Info().Ctx(ctx)).Send()
+
+		records := recorder.Records()
+		require.Len(t, records, 3)
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 3)
+
+		for i, record := range records {
+			caller := file + ":" + strconv.Itoa(line+i+1)
+			assert.Contains(t, lines[i], strconv.Quote(caller))
+			assert.Equal(t, otelLog.StringValue(file), record.Attributes()["code.filepath"])
+			assert.Equal(t, otelLog.Int64Value(int64(line+i+1)), record.Attributes()["code.lineno"])
+		}
+	})
+
+	t.Run("raw json", func(t *testing.T) {
+		recorder := newRecorder(t)
+		logger := zerolog.New(io.Discard).Hook(&Hook{otelLogger: recorder.Logger("test")})
+
+		NewEvent(logger.Info().Ctx(t.Context())).
+			RawJSON("object", []byte(`{"b":1,"a":"x"}`)).
+			RawJSON("array", []byte(`[1, 2]`)).
+			Msg("test message")
+
+		records := recorder.Records()
+		require.Len(t, records, 1)
+		attrs := records[0].Attributes()
+
+		assert.Equal(t, otelLog.MapValue(otelLog.String("a", "x"), otelLog.Float64("b", 1)), attrs["object"])
+		assert.Equal(t, otelLog.StringValue(`[1, 2]`), attrs["array"])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		recorder := newRecorder(t)
+		logger := zerolog.New(io.Discard).Level(zerolog.WarnLevel).Hook(&Hook{otelLogger: recorder.Logger("test")})
+
+		NewEvent(logger.Info()).
+			Dur("dur", time.Second).
+			Msg("test message")
+
+		assert.Empty(t, recorder.Records())
+	})
+}
@@ -0,0 +1,116 @@
+// Package otelzlog exporter holds the otel log exporter that writes otel log
+// records back out through a zerolog logger
+package otelzlog
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// Fields added by the [Exporter] from the record, alongside its attributes.
+const (
+	exporterScopeKey     = "otel.scope.name"
+	exporterEventNameKey = "event.name"
+	exporterTraceIDKey   = "trace_id"
+	exporterSpanIDKey    = "span_id"
+	exporterBodyKey      = "body"
+)
+
+// Exporter is an sdk/log Exporter that writes otel log records through a
+// zerolog.Logger, so that logs from otel-instrumented dependencies (otelslog,
+// otellogr, contrib instrumentations) show up in the same format as the rest of
+// the application's logs, e.g.
+//
+//	sdklog.NewLoggerProvider(sdklog.WithProcessor(
+//		sdklog.NewSimpleProcessor(otelzlog.NewExporter(zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout}))),
+//	))
+//
+// Severities are mapped back to zerolog levels, and attributes are written as typed
+// zerolog fields. A string body is written as the message, any other body as a
+// "body" field. The record's timestamp is written as the timestamp field, so the
+// logger shouldn't add its own.
+//
+// Records are written with a context that the [Hook] skips, so the logger may
+// be the one returned by [New] without the records looping back into otel.
+type Exporter struct {
+	logger   zerolog.Logger
+	shutdown atomic.Bool
+}
+
+var _ sdklog.Exporter = (*Exporter)(nil)
+
+// NewExporter creates an [Exporter] that writes through logger.
+func NewExporter(logger zerolog.Logger) *Exporter {
+	return &Exporter{logger: logger}
+}
+
+// Export writes each record through the zerolog logger. It does nothing once the
+// Exporter is shut down.
+func (e *Exporter) Export(ctx context.Context, records []sdklog.Record) error {
+	if e.shutdown.Load() {
+		return nil
+	}
+
+	// mark the context as emitted by otel so that a hooked logger doesn't export the
+	// records again
+	ctx = context.WithValue(ctx, emittingKey{}, true)
+
+	for _, record := range records {
+		event := e.logger.WithLevel(convertSeverity(record.Severity())).Ctx(ctx)
+		if !event.Enabled() {
+			continue
+		}
+
+		if ts := record.Timestamp(); !ts.IsZero() {
+			event.Time(zerolog.TimestampFieldName, ts)
+		}
+
+		if scope := record.InstrumentationScope().Name; scope != "" {
+			event.Str(exporterScopeKey, scope)
+		}
+
+		if name := record.EventName(); name != "" {
+			event.Str(exporterEventNameKey, name)
+		}
+
+		if record.TraceID().IsValid() {
+			event.Str(exporterTraceIDKey, record.TraceID().String())
+		}
+		if record.SpanID().IsValid() {
+			event.Str(exporterSpanIDKey, record.SpanID().String())
+		}
+
+		record.WalkAttributes(func(kv log.KeyValue) bool {
+			convertValueToField(event, kv.Key, kv.Value)
+			return true
+		})
+
+		body := record.Body()
+		if body.Kind() == log.KindString {
+			event.Msg(body.AsString())
+			continue
+		}
+
+		if body.Kind() != log.KindEmpty {
+			convertValueToField(event, exporterBodyKey, body)
+		}
+		event.Send()
+	}
+
+	return nil
+}
+
+// Shutdown stops the Exporter from writing any further records.
+func (e *Exporter) Shutdown(context.Context) error {
+	e.shutdown.Store(true)
+	return nil
+}
+
+// ForceFlush does nothing, as records are written as they are exported.
+func (e *Exporter) ForceFlush(context.Context) error {
+	return nil
+}
@@ -0,0 +1,110 @@
+package otelzlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otelLog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+func TestExporter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(
+		sdklog.NewSimpleProcessor(NewExporter(zerolog.New(buf))),
+	))
+
+	ctx, span := newRecorder(t).TracerProvider().Tracer("test").Start(t.Context(), "test.segment")
+	defer span.End()
+
+	now := time.Date(2025, time.June, 1, 12, 30, 45, 0, time.UTC)
+	record := otelLog.Record{}
+	record.SetTimestamp(now)
+	record.SetSeverity(otelLog.SeverityWarn2)
+	record.SetBody(otelLog.StringValue("test log"))
+	record.SetEventName("test.event")
+	record.AddAttributes(
+		otelLog.String("str", "value"),
+		otelLog.Int64("int", 10),
+		otelLog.Float64("float", 0.5),
+		otelLog.Bool("bool", true),
+		otelLog.Slice("slice", otelLog.StringValue("a"), otelLog.StringValue("b")),
+		otelLog.Map("map", otelLog.String("a", "b")),
+	)
+	provider.Logger("dependency").Emit(ctx, record)
+
+	line := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, map[string]any{
+		"level":           "warn",
+		"time":            now.Format(zerolog.TimeFieldFormat),
+		"message":         "test log",
+		"otel.scope.name": "dependency",
+		"event.name":      "test.event",
+		"trace_id":        span.SpanContext().TraceID().String(),
+		"span_id":         span.SpanContext().SpanID().String(),
+		"str":             "value",
+		"int":             10.0,
+		"float":           0.5,
+		"bool":            true,
+		"slice":           []any{"a", "b"},
+		"map":             map[string]any{"a": "b"},
+	}, line)
+}
+
+func TestExporterBody(t *testing.T) {
+	buf := new(bytes.Buffer)
+	exporter := NewExporter(zerolog.New(buf))
+
+	record := sdklog.Record{}
+	record.SetSeverity(otelLog.SeverityInfo)
+	record.SetBody(otelLog.MapValue(otelLog.String("a", "b")))
+	require.NoError(t, exporter.Export(t.Context(), []sdklog.Record{record}))
+
+	assert.JSONEq(t, `{"level":"info","body":{"a":"b"}}`, buf.String())
+}
+
+func TestExporterLevel(t *testing.T) {
+	buf := new(bytes.Buffer)
+	exporter := NewExporter(zerolog.New(buf).Level(zerolog.WarnLevel))
+
+	record := sdklog.Record{}
+	record.SetSeverity(otelLog.SeverityInfo)
+	record.SetBody(otelLog.StringValue("test log"))
+	require.NoError(t, exporter.Export(t.Context(), []sdklog.Record{record}))
+
+	assert.Empty(t, buf.String())
+}
+
+func TestExporterHookedLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	recorder := newRecorder(t)
+	exporter := NewExporter(zerolog.New(buf).Hook(&Hook{otelLogger: recorder.Logger("test")}))
+
+	record := sdklog.Record{}
+	record.SetSeverity(otelLog.SeverityInfo)
+	record.SetBody(otelLog.StringValue("test log"))
+	require.NoError(t, exporter.Export(t.Context(), []sdklog.Record{record}))
+
+	assert.Contains(t, buf.String(), "test log")
+	assert.Empty(t, recorder.Records(), "exported records must not loop back into otel")
+}
+
+func TestExporterShutdown(t *testing.T) {
+	buf := new(bytes.Buffer)
+	exporter := NewExporter(zerolog.New(buf))
+
+	require.NoError(t, exporter.ForceFlush(t.Context()))
+	require.NoError(t, exporter.Shutdown(t.Context()))
+
+	record := sdklog.Record{}
+	record.SetBody(otelLog.StringValue("test log"))
+	require.NoError(t, exporter.Export(t.Context(), []sdklog.Record{record}))
+
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,82 @@
+// Package otelzlog flush holds the flushing of the otel pipeline before a fatal
+// or panic event terminates the process
+package otelzlog
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultFlushTimeout bounds the flush on fatal and panic events if no timeout is configured.
+const defaultFlushTimeout = 5 * time.Second
+
+// forceFlusher is implemented by the SDK logger and tracer providers.
+type forceFlusher interface {
+	ForceFlush(ctx context.Context) error
+}
+
+// isTerminal reports whether the event terminates the process (or the goroutine)
+// once the hook returns, and flushing is enabled for it.
+func (h *Hook) isTerminal(level zerolog.Level) bool {
+	return h.flushOnFatal && (level == zerolog.FatalLevel || level == zerolog.PanicLevel)
+}
+
+// flushTerminal ends the span in ctx and synchronously flushes the queue of the [Hook]
+// and the logger and tracer providers, so that the record explaining a fatal or panic
+// event is exported before zerolog exits or panics.
+func (h *Hook) flushTerminal(ctx context.Context) {
+	// the span can't be ended by the application anymore, and an open span is never
+	// exported, unless the panic is being recovered
+	if span := trace.SpanFromContext(ctx); span.IsRecording() && !isRecovered(ctx) {
+		span.End()
+	}
+
+	h.forceFlush(ctx)
+}
+
+// forceFlush drains the queue of the [Hook] and force flushes the logger and tracer
+// providers if they support it, within the flush timeout. Errors are counted as emit
+// errors and passed to the error handler.
+func (h *Hook) forceFlush(ctx context.Context) {
+	timeout := h.flushTimeout
+	if timeout <= 0 {
+		timeout = defaultFlushTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	if err := h.Flush(ctx); err != nil {
+		h.diag.emitErrors.Add(1)
+		h.handleError(err)
+	}
+
+	for _, provider := range h.flushProviders() {
+		if f, ok := provider.(forceFlusher); ok {
+			if err := f.ForceFlush(ctx); err != nil {
+				h.diag.emitErrors.Add(1)
+				h.handleError(err)
+			}
+		}
+	}
+}
+
+// flushProviders returns the logger and tracer providers to flush. Providers taken from
+// the otel globals by [New] are looked up again, as they are otel's delegating providers
+// if New was called before the SDK providers were registered, which can't be flushed.
+func (h *Hook) flushProviders() []any {
+	loggerProvider, tracerProvider := any(h.loggerProvider), any(h.tracerProvider)
+	if h.globalLoggerProvider {
+		loggerProvider = global.GetLoggerProvider()
+	}
+	if h.globalTracerProvider {
+		tracerProvider = otel.GetTracerProvider()
+	}
+
+	return []any{loggerProvider, tracerProvider}
+}
@@ -0,0 +1,83 @@
+package otelzlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	otelLog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+)
+
+// flushingProvider is a logger provider that counts the calls to ForceFlush.
+type flushingProvider struct {
+	otelLog.LoggerProvider
+	flushed int
+}
+
+func (p *flushingProvider) ForceFlush(context.Context) error {
+	p.flushed++
+	return nil
+}
+
+func TestHookFlushOnFatal(t *testing.T) {
+	for _, level := range []zerolog.Level{zerolog.FatalLevel, zerolog.PanicLevel} {
+		recorder := newRecorder(t)
+		provider := &flushingProvider{}
+
+		hook := &Hook{
+			otelLogger:     recorder.Logger("test"),
+			loggerProvider: provider,
+			tracerProvider: recorder.TracerProvider(),
+			flushOnFatal:   true,
+		}
+		hook.async = newAsyncEmitter(recorder.Logger("test").Emit, 10, Block)
+		logger := zerolog.New(new(bytes.Buffer)).Hook(hook)
+
+		ctx, _ := recorder.TracerProvider().Tracer("test").Start(t.Context(), "test.segment")
+
+		// WithLevel logs at the level without exiting or panicking
+		logger.WithLevel(level).Ctx(ctx).Msg("out of memory")
+
+		assert.Len(t, recorder.Records(), 1, "the queue must be drained before the hook returns")
+		assert.Equal(t, 1, provider.flushed)
+
+		spans := recorder.Spans()
+		require.Len(t, spans, 1, "the span must be ended before the hook returns")
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Equal(t, "out of memory", spans[0].Status().Description)
+	}
+}
+
+func TestHookFlushOnFatalLevels(t *testing.T) {
+	provider := &flushingProvider{}
+	hook := &Hook{otelLogger: newRecorder(t).Logger("test"), loggerProvider: provider, flushOnFatal: true}
+	logger := zerolog.New(new(bytes.Buffer)).Hook(hook)
+
+	logger.Error().Msg("test log")
+	assert.Zero(t, provider.flushed)
+
+	hook.flushOnFatal = false
+	logger.WithLevel(zerolog.FatalLevel).Msg("test log")
+	assert.Zero(t, provider.flushed)
+}
+
+func TestFlushOnFatalGlobalProviders(t *testing.T) {
+	previous := global.GetLoggerProvider()
+	t.Cleanup(func() { global.SetLoggerProvider(previous) })
+
+	// New is called before the SDK provider is registered globally
+	ctx := New(t.Context(), "test", WithFlushOnFatal(true, time.Second), WithWriter(new(bytes.Buffer)))
+
+	provider := &flushingProvider{LoggerProvider: newRecorder(t)}
+	global.SetLoggerProvider(provider)
+
+	zerolog.Ctx(ctx).WithLevel(zerolog.FatalLevel).Msg("out of memory")
+
+	assert.Equal(t, 1, provider.flushed)
+}
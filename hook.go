@@ -5,11 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
-	zlog "github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	otelLog "go.opentelemetry.io/otel/log"
@@ -17,14 +19,65 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// exceptionEventName is the semconv event name for exceptions, used as the event name of
+// records that carry an error. Span events keep the message as their name, with the
+// exception in their exception.* attributes.
+const exceptionEventName = "exception"
+
+const (
+	// originalLineKey is the attribute that holds the raw zerolog line of an event that
+	// could not be decoded. It is left out when attribute processors are configured, as
+	// they can't redact the fields inside it.
+	originalLineKey = "log.original"
+	// decodeErrorKey is the attribute that holds the decoding error of an event that could
+	// not be decoded.
+	decodeErrorKey = "otelzlog.decode_error"
+)
+
+// instrumentationName is the instrumentation scope name of the spans and metrics
+// created by the hook.
+const instrumentationName = "github.com/adreasnow/otelzlog"
+
 // Hook is the parent struct of the otelzlog handler
 type Hook struct {
 	otelLogger        otelLog.Logger
+	loggerProvider    otelLog.LoggerProvider
 	source            bool
 	attachSpanError   bool
 	attachSpanEvent   bool
 	setSpanError      bool
 	setSpanErrorLevel zerolog.Level
+	fieldTypes        map[string]FieldType
+	processors        []AttributeProcessor
+	logLimits         attributeLimits
+	spanLimits        attributeLimits
+	bodyMode          BodyMode
+	eventNameField    string
+	spanEventFormat   SpanEventFormatter
+	spanStatusFormat  SpanStatusFormatter
+	setSpanOk         bool
+	setSpanOkLevel    zerolog.Level
+	setSpanOkField    string
+	spanErrorPolicy   SpanErrorPolicy
+	tracerProvider    trace.TracerProvider
+	orphanSpans       bool
+	orphanSpanLevel   zerolog.Level
+	metrics           *logMetrics
+	sampler           *traceSampler
+	dedup             *deduplicator
+	async             *asyncEmitter
+	flushOnFatal      bool
+	flushTimeout      time.Duration
+	errorHandler      func(error)
+	flattenMaps       bool
+	rawJSONBytes      bool
+	spanStatuses      spanStatuses
+	diag              diagnostics
+
+	// globalLoggerProvider and globalTracerProvider are set if the providers were
+	// taken from the otel globals, and so are looked up again when flushing
+	globalLoggerProvider bool
+	globalTracerProvider bool
 }
 
 // Run extracts the attributes and log level from the `*zerolog.Event`, and
@@ -38,102 +91,468 @@ func (h *Hook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
 		return
 	}
 
-	var logData map[string]any
-	ev := fmt.Sprintf("%s}", reflect.ValueOf(e).Elem().FieldByName("buf"))
-	if err := json.Unmarshal([]byte(ev), &logData); err != nil {
-		// log to the zerolog logger if there is an error reflecting the event's attribute buffer
-		zlog.Ctx(e.GetCtx()).Error().Ctx(e.GetCtx()).
-			Err(err).
-			Str("log.level", level.String()).
-			Str("log.message", msg).
-			Msg("could not unmarshal the zerolog event's attribute buffer")
+	// events logged from within the otel pipeline are left to the writers, exporting
+	// them would re-enter the pipeline
+	if isEmitting(ctx) {
+		return
+	}
+
+	// return early if the event is sampled out of the export, leaving the writers untouched,
+	// but still count it in the metrics
+	if !h.sampler.shouldExport(ctx, level) {
+		h.recordMetrics(ctx, e, level)
+		return
+	}
+
+	ev := eventJSON(e)
+	logData, err := decodeEvent(ev)
+	if err != nil {
+		// report the failure out of band, logging it would run it through the hook again
+		h.diag.decodeFailures.Add(1)
+		h.handleError(fmt.Errorf("otelzlog: could not unmarshal the %s event %q's attribute buffer: %w", level, msg, err))
+
+		// keep the fields before the invalid one, and the raw line so that the rest isn't
+		// lost, unless it could carry fields that the processors would drop or redact
+		logData = decodeEventPrefix(ev)
+		logData[decodeErrorKey] = err.Error()
+		if h.bodyMode != BodyRawJSON && len(h.processors) == 0 {
+			logData[originalLineKey] = rawJSONLine(ev, msg)
+		}
+	}
+
+	// give events logged outside of a span a span of their own, so that they still
+	// reach the tracing backend
+	ctx, orphanSpan := h.startOrphanSpan(ctx, level, msg)
+	if orphanSpan != nil {
+		defer orphanSpan.End()
 	}
 
 	// convert zerolog attrs into otel log and span attrs
-	logAttributes := h.processSpanAttrs(ctx, msg, logData, level)
+	eventName, logAttributes := h.processSpanAttrs(ctx, msg, logData, level, orphanSpan != nil)
 
 	// create the otel log event and send it
-	h.sendLogMessage(ctx, msg, level, logAttributes)
+	h.sendLogMessage(ctx, msg, eventName, ev, level, logAttributes)
+
+	// zerolog exits or panics as soon as the hook returns from a fatal or panic event
+	if h.isTerminal(level) {
+		h.flushTerminal(ctx)
+	}
+}
+
+// eventJSON returns the JSON of the fields of the `*zerolog.Event`, without the message.
+func eventJSON(e *zerolog.Event) string {
+	return fmt.Sprintf("%s}", reflect.ValueOf(e).Elem().FieldByName("buf"))
+}
+
+// recordMetrics records an event that isn't exported in the log metrics, if enabled.
+func (h *Hook) recordMetrics(ctx context.Context, e *zerolog.Event, level zerolog.Level) {
+	if h.metrics == nil {
+		return
+	}
+
+	ev := eventJSON(e)
+	logData, err := decodeEvent(ev)
+	if err != nil {
+		logData = decodeEventPrefix(ev)
+	}
+
+	var logErr error
+	if v, ok := logData[zerolog.ErrorFieldName]; ok {
+		logErr = eventError(typedFieldsFromContext(ctx), fieldString(v))
+	}
+
+	h.metrics.record(ctx, level, logErr, logData)
 }
 
 // processSpanAttrs converts each pulled attribute into the equivalent otel log counterparts.
 // It also adds the attributes into the span and adds the error as an exception.
-func (h *Hook) processSpanAttrs(ctx context.Context, msg string, logData map[string]any, level zerolog.Level) (logAttributes []otelLog.KeyValue) {
-	for k, v := range logData {
+// If an event name field is configured, its value is returned as the event name
+// instead of being added to the attributes. Orphaned events always get a span event and
+// an error status on the span created for them.
+func (h *Hook) processSpanAttrs(ctx context.Context, msg string, logData map[string]any, level zerolog.Level, orphaned bool) (eventName string, logAttributes []otelLog.KeyValue) {
+	// fields written through an [Event] keep their native types
+	typed := typedFieldsFromContext(ctx)
+
+	// attributes derived by the hook are kept apart so that they never clobber user fields
+	var hookAttributes, exceptionAttributes []otelLog.KeyValue
+	var logErr error
+
+	// iterate in key order so that attribute limits drop the same fields every time
+	for _, k := range slices.Sorted(maps.Keys(logData)) {
+		v := logData[k]
+
+		// lift the event name field out of the attributes
+		if h.eventNameField != "" && k == h.eventNameField {
+			if name, ok := v.(string); ok {
+				eventName = name
+				continue
+			}
+		}
+
 		switch k {
-		// if there is an attribute called "error", then record the error in the span and
-		// add it to the log attributes only (not the trace attributes)
+		// if there is an attribute called "error", then record it as an exception
+		// using the semconv exception attributes
 		case zerolog.ErrorFieldName:
-			logAttributes = append(logAttributes,
-				otelLog.String(string(semconv.ExceptionMessageKey), v.(string)),
-				otelLog.String("event", "exception"),
-			)
+			logErr = eventError(typed, fieldString(v))
+			if value, keep := h.processFieldValue(k, otelLog.StringValue(logErr.Error())); keep {
+				exceptionAttributes = append(exceptionAttributes,
+					otelLog.KeyValue{Key: string(semconv.ExceptionMessageKey), Value: value},
+				)
+			}
 
 		// if there is an attribute called "stack", then record the stack in the span and
 		// add it to the log attributes only (not the trace attributes)
 		case zerolog.ErrorStackFieldName:
-			logAttributes = append(logAttributes,
-				otelLog.String(string(semconv.ExceptionStacktraceKey), v.(string)),
-			)
+			if value, keep := h.processFieldValue(k, otelLog.StringValue(fieldString(v))); keep {
+				exceptionAttributes = append(exceptionAttributes,
+					otelLog.KeyValue{Key: string(semconv.ExceptionStacktraceKey), Value: value},
+				)
+			}
 
 		// If there is a "caller" object in the log and if source is enabled in [Hook], then
 		// append these using semconv fields instead of generic string attributes.
 		case zerolog.CallerFieldName:
 			sourcePath, ok := v.(string)
+			if caller, isTyped := typed[k].(string); isTyped {
+				sourcePath, ok = caller, true
+			}
 			if !ok || !h.source {
 				continue
 			}
 
+			value, keep := h.processFieldValue(k, otelLog.StringValue(sourcePath))
+			if !keep || value.Kind() != otelLog.KindString {
+				continue
+			}
+			sourcePath = value.AsString()
+
 			filepath, line, err := extractSource(sourcePath)
 			if err != nil {
 				continue
 			}
 
-			logAttributes = append(logAttributes,
+			hookAttributes = append(hookAttributes,
 				otelLog.String(string(semconv.CodeFilepathKey), filepath),
 				otelLog.Int(string(semconv.CodeLineNumberKey), line),
 			)
 
 		default:
-			logAttributes = append(logAttributes, otelLog.KeyValue{
-				Key:   k,
-				Value: convertAttribute(v),
-			})
+			var value otelLog.Value
+			if raw, ok := typed[k].(json.RawMessage); ok {
+				value = convertRawJSON(raw, v, h.rawJSONBytes)
+			} else if native, ok := typed[k]; ok {
+				value = convertAttribute(native)
+			} else if converted, ok := convertFieldType(v, h.fieldType(k)); ok {
+				value = convertAttribute(converted)
+			} else {
+				value = convertAttribute(v)
+			}
+
+			kv := otelLog.KeyValue{Key: k, Value: value}
+			if h.flattenMaps {
+				logAttributes = append(logAttributes, flattenAttribute(kv)...)
+			} else {
+				logAttributes = append(logAttributes, kv)
+			}
 		}
 	}
 
-	// If enabled, add an otel span event (attach the log to the span).
-	if h.attachSpanEvent {
+	// errors rejected by the SpanErrorPolicy are not exceptions, so the span event is
+	// left without the exception attributes derived for them
+	isException := logErr != nil && h.isException(level, logErr, logData)
+	var spanExcluded []string
+	if logErr != nil && !isException {
+		for _, kv := range exceptionAttributes {
+			if !slices.ContainsFunc(logAttributes, func(attr otelLog.KeyValue) bool { return attr.Key == kv.Key }) {
+				spanExcluded = append(spanExcluded, kv.Key)
+			}
+		}
+	}
+
+	logAttributes = h.mergeHookAttributes(logAttributes, append(exceptionAttributes, hookAttributes...))
+
+	// span events are named after the event name field or the message, not the exception
+	spanEventName := eventName
+	if spanEventName == "" {
+		spanEventName = msg
+	}
+
+	// mark errors with the semconv exception event name unless the event is already named
+	if isException && eventName == "" {
+		eventName = exceptionEventName
+	}
+
+	// rename, drop and redact attributes before they reach either the log or the span
+	logAttributes = processAttributes(logAttributes, h.processors)
+
+	// If enabled, add an otel span event (attach the log to the span). Recovered panics
+	// are already recorded on the span as an exception.
+	if (h.attachSpanEvent && !isRecovered(ctx)) || orphaned {
 		traceAttributes := []attribute.KeyValue{}
 
-		for _, logAttr := range logAttributes {
+		spanAttributes := logAttributes
+		if len(spanExcluded) > 0 {
+			spanAttributes = slices.DeleteFunc(slices.Clone(logAttributes), func(kv otelLog.KeyValue) bool {
+				return slices.Contains(spanExcluded, kv.Key)
+			})
+		}
+
+		spanAttributes, counts := h.spanLimits.apply(spanAttributes)
+		h.diag.recordLimits(counts)
+
+		for _, logAttr := range spanAttributes {
 			traceAttributes = append(traceAttributes, attribute.KeyValue{
 				Key:   attribute.Key(logAttr.Key),
 				Value: convertLogToAttribute(logAttr.Value),
 			})
 		}
 
-		trace.SpanFromContext(ctx).AddEvent(msg,
+		if spanEventName == "" {
+			spanEventName = eventName
+		}
+
+		if h.spanEventFormat != nil {
+			spanEventName, traceAttributes = h.spanEventFormat(level, msg, traceAttributes)
+		}
+
+		trace.SpanFromContext(ctx).AddEvent(spanEventName,
 			trace.WithAttributes(traceAttributes...),
 		)
 	}
 
-	if h.setSpanError && level >= h.setSpanErrorLevel {
-		trace.SpanFromContext(ctx).SetStatus(codes.Error, "")
+	// the span status describes the error as the processors left it
+	errMsg := ""
+	if i := slices.IndexFunc(logAttributes, func(kv otelLog.KeyValue) bool {
+		return kv.Key == string(semconv.ExceptionMessageKey)
+	}); logErr != nil && i >= 0 {
+		errMsg = logAttributes[i].Value.String()
+	}
+
+	h.setSpanStatus(ctx, level, msg, logErr, errMsg, logData, orphaned)
+
+	h.metrics.record(ctx, level, logErr, logData)
+
+	logAttributes, counts := h.logLimits.apply(logAttributes)
+	h.diag.recordLimits(counts)
+
+	return eventName, logAttributes
+}
+
+// processFieldValue runs the value of the zerolog error, stack or caller field through the
+// processors under its zerolog key, before the attribute derived from it is run through
+// them under its semconv key with the other attributes. The writers run the processors
+// over the same two names, see processField.
+func (h *Hook) processFieldValue(key string, value otelLog.Value) (otelLog.Value, bool) {
+	kv, keep := processAttribute(otelLog.KeyValue{Key: key, Value: value}, h.processors)
+	return kv.Value, keep
+}
+
+// setSpanStatus sets the span status to error or ok if the event qualifies. A status
+// that has already been set is never changed, so a later, less severe log can't
+// downgrade an error and an ok status set by the application is respected.
+func (h *Hook) setSpanStatus(ctx context.Context, level zerolog.Level, msg string, logErr error, errMsg string, logData map[string]any, orphaned bool) {
+	span := trace.SpanFromContext(ctx)
+	if h.spanStatuses.code(span) != codes.Unset {
+		return
+	}
+
+	switch {
+	case orphaned || h.isTerminal(level) || h.isSpanError(level, logErr, logData):
+		format := h.spanStatusFormat
+		if format == nil {
+			format = defaultSpanStatusFormatter
+		}
+
+		h.spanStatuses.set(span, codes.Error, format(level, msg, errMsg))
+
+	case h.setSpanOk && h.isSuccess(level, logData):
+		h.spanStatuses.set(span, codes.Ok, "")
+	}
+}
+
+// startOrphanSpan starts a span for events at or above the orphan span level that are
+// logged without a recording span in their context. It returns a nil span if none was started.
+func (h *Hook) startOrphanSpan(ctx context.Context, level zerolog.Level, msg string) (context.Context, trace.Span) {
+	if !h.orphanSpans || h.tracerProvider == nil || level < h.orphanSpanLevel {
+		return ctx, nil
+	}
+
+	if trace.SpanFromContext(ctx).IsRecording() {
+		return ctx, nil
+	}
+
+	name := msg
+	if name == "" {
+		name = "log." + level.String()
+	}
+
+	return h.tracerProvider.Tracer(instrumentationName).Start(ctx, name)
+}
+
+// isSpanError reports whether the event should mark its span as errored, using the
+// [SpanErrorPolicy] if one is configured and the level threshold otherwise.
+func (h *Hook) isSpanError(level zerolog.Level, logErr error, logData map[string]any) bool {
+	if h.spanErrorPolicy != nil {
+		return h.spanErrorPolicy(level, logErr, logData)
+	}
+
+	return h.setSpanError && level >= h.setSpanErrorLevel
+}
+
+// isException reports whether an event carrying an error should be recorded as an
+// exception. Every error is, unless a [SpanErrorPolicy] is configured and rejects it.
+func (h *Hook) isException(level zerolog.Level, logErr error, logData map[string]any) bool {
+	return h.spanErrorPolicy == nil || h.spanErrorPolicy(level, logErr, logData)
+}
+
+// eventError returns the error attached to the event, as written through [Event.Err]
+// if available, or rebuilt from its message otherwise.
+func eventError(typed map[string]any, errMsg string) error {
+	if err, ok := typed[zerolog.ErrorFieldName].(error); ok {
+		return err
+	}
+	return messageError(errMsg)
+}
+
+// messageError is an error rebuilt from the message zerolog wrote for it.
+type messageError string
+
+func (e messageError) Error() string {
+	return string(e)
+}
+
+// isSuccess reports whether the event is at the configured success level, or has the
+// configured success field set to true.
+func (h *Hook) isSuccess(level zerolog.Level, logData map[string]any) bool {
+	if h.setSpanOkLevel != zerolog.Disabled && level == h.setSpanOkLevel {
+		return true
+	}
+
+	if h.setSpanOkField != "" {
+		success, ok := logData[h.setSpanOkField].(bool)
+		return ok && success
+	}
+
+	return false
+}
+
+// mergeHookAttributes puts the attributes derived by the hook before the user's attributes,
+// so that attribute count limits drop user fields first. A user field always takes
+// precedence over a derived attribute with the same key, and the conflict is reported
+// to the error handler.
+func (h *Hook) mergeHookAttributes(logAttributes []otelLog.KeyValue, hookAttributes []otelLog.KeyValue) []otelLog.KeyValue {
+	merged := make([]otelLog.KeyValue, 0, len(hookAttributes)+len(logAttributes))
+	for _, kv := range hookAttributes {
+		if slices.ContainsFunc(logAttributes, func(attr otelLog.KeyValue) bool { return attr.Key == kv.Key }) {
+			h.handleError(fmt.Errorf("otelzlog: field %q conflicts with the attribute derived by the hook and was kept as-is", kv.Key))
+			continue
+		}
+		merged = append(merged, kv)
+	}
+
+	return append(merged, logAttributes...)
+}
+
+// fieldType returns the [FieldType] hint configured for the field key, matching
+// exact names before "*" suffix patterns, and longer suffixes before shorter ones.
+func (h *Hook) fieldType(key string) FieldType {
+	if fieldType, ok := h.fieldTypes[key]; ok {
+		return fieldType
+	}
+
+	matched := FieldTypeDefault
+	matchedLen := -1
+	for pattern, fieldType := range h.fieldTypes {
+		suffix, ok := strings.CutPrefix(pattern, "*")
+		if ok && strings.HasSuffix(key, suffix) && len(suffix) > matchedLen {
+			matched = fieldType
+			matchedLen = len(suffix)
+		}
 	}
 
-	return
+	return matched
 }
 
-func (h *Hook) sendLogMessage(ctx context.Context, msg string, level zerolog.Level, logAttributes []otelLog.KeyValue) {
+// body builds the otel log record body according to the [BodyMode] of the [Hook].
+func (h *Hook) body(msg string, rawJSON string, logAttributes []otelLog.KeyValue) otelLog.Value {
+	switch h.bodyMode {
+	case BodyMap:
+		kvs := make([]otelLog.KeyValue, 0, len(logAttributes)+1)
+		kvs = append(kvs, otelLog.String(zerolog.MessageFieldName, msg))
+		kvs = append(kvs, logAttributes...)
+		return otelLog.MapValue(kvs...)
+
+	case BodyRawJSON:
+		// the raw line hasn't been through the processors and limits, so it is rebuilt
+		// from the attributes when any are configured
+		if len(h.processors) > 0 || h.logLimits.enabled() {
+			return otelLog.StringValue(attributesJSONLine(msg, logAttributes))
+		}
+		return otelLog.StringValue(rawJSONLine(rawJSON, msg))
+
+	default:
+		return otelLog.StringValue(msg)
+	}
+}
+
+func (h *Hook) sendLogMessage(ctx context.Context, msg string, eventName string, rawJSON string, level zerolog.Level, logAttributes []otelLog.KeyValue) {
 	severityNumber, severityText := convertLevel(level)
 
 	record := otelLog.Record{}
 	record.SetTimestamp(time.Now())
-	record.SetBody(otelLog.StringValue(msg))
+	record.SetBody(h.body(msg, rawJSON, logAttributes))
 	record.SetSeverity(severityNumber)
 	record.SetSeverityText(severityText)
+	record.SetEventName(eventName)
 	record.AddAttributes(logAttributes...)
 
-	h.otelLogger.Emit(ctx, record)
+	// suppress duplicates of a recently exported record, these are summarised once the window ends
+	if !h.dedup.allow(ctx, dedupKeyFor(msg, level, logAttributes), record) {
+		return
+	}
+
+	h.emit(ctx, record)
+}
+
+// emit sends the record to the otel logger, through the queue if the [Hook] is asynchronous.
+func (h *Hook) emit(ctx context.Context, record otelLog.Record) {
+	if h.async != nil {
+		h.async.enqueue(ctx, record)
+		return
+	}
+
+	h.exportRecord(ctx, record)
+}
+
+// Flush waits until every record queued by an asynchronous [Hook] has been
+// emitted, or ctx is done. It should be called before shutting down the
+// LoggerProvider. It returns immediately if the Hook is synchronous.
+func (h *Hook) Flush(ctx context.Context) error {
+	if h.async == nil {
+		return nil
+	}
+
+	return h.async.flush(ctx)
+}
+
+// Shutdown drains the queue of an asynchronous [Hook], then stops its worker goroutine,
+// waiting until every queued record has been emitted or ctx is done. Records logged
+// afterwards are dropped. It should be called before shutting down the LoggerProvider.
+// It returns immediately if the Hook is synchronous.
+func (h *Hook) Shutdown(ctx context.Context) error {
+	if h.async == nil {
+		return nil
+	}
+
+	return h.async.shutdown(ctx)
+}
+
+// DroppedRecords returns the number of records an asynchronous [Hook] has dropped
+// because its queue was full, or because it was shut down.
+func (h *Hook) DroppedRecords() uint64 {
+	if h.async == nil {
+		return 0
+	}
+
+	return h.async.dropped.Load()
 }
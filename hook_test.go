@@ -2,239 +2,175 @@ package otelzlog
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/adreasnow/otelstack/jaeger"
-	"github.com/adreasnow/otelstack/seq"
+	"github.com/adreasnow/otelzlog/otelzlogtest"
 	"github.com/pkg/errors"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"go.opentelemetry.io/otel"
-	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otelLog "go.opentelemetry.io/otel/log"
 	"go.opentelemetry.io/otel/trace"
 
 	otelLogGlobal "go.opentelemetry.io/otel/log/global"
 )
 
+// TestHookCollector sends an event through an otel collector, to check that the
+// records and span events produced by the hook survive the otlp exporters.
+func TestHookCollector(t *testing.T) {
+	stack := setupOTELStack(t)
+
+	ctx := log.
+		Hook(&Hook{
+			otelLogger:      otelLogGlobal.GetLoggerProvider().Logger("test"),
+			attachSpanError: true,
+			attachSpanEvent: true,
+		}).
+		WithContext(t.Context())
+
+	spanID, traceID := sendTestEvents(ctx, t)
+
+	time.Sleep(time.Second * 30)
+
+	checkEvents(t, stack, spanID, traceID)
+}
+
 func TestHook(t *testing.T) {
 	t.Run("basic", func(t *testing.T) {
-		stack := setupOTELStack(t)
+		rec := newRecorder(t)
 
-		ctx := log.
+		ctx := zerolog.New(io.Discard).
 			Hook(&Hook{
-				otelLogger:      otelLogGlobal.GetLoggerProvider().Logger("test"),
+				otelLogger:      rec.Logger("test"),
 				attachSpanError: true,
 				attachSpanEvent: true,
 			}).
 			WithContext(t.Context())
 
-		spanID, traceID := sendTestEvents(ctx, t)
-
-		time.Sleep(time.Second * 30)
-
-		checkEvents(t, stack, spanID, traceID)
+		ctx, span := rec.TracerProvider().Tracer(serviceName).Start(ctx, "test.segment")
+		log.Ctx(ctx).Info().Ctx(ctx).Str("test-key", "test-value").Msg("test log")
+		span.End()
+
+		records := rec.Records()
+		require.Len(t, records, 1)
+		otelzlogtest.AssertRecord(t, records[0], otelLog.SeverityInfo, "test log",
+			otelLog.String("test-key", "test-value"),
+			otelLog.String("level", "info"),
+		)
+		assert.Equal(t, span.SpanContext(), records[0].SpanContext)
+
+		events := rec.SpanEvents("test.segment")
+		require.Len(t, events, 1)
+		assert.Equal(t, "test log", events[0].Name)
+		assert.ElementsMatch(t, []attribute.KeyValue{
+			attribute.String("test-key", "test-value"),
+			attribute.String("level", "info"),
+		}, events[0].Attributes)
 	})
 
 	t.Run("error without attaching to span", func(t *testing.T) {
-		stack := setupOTELStack(t)
+		rec := newRecorder(t)
 
-		ctx := log.
+		ctx := zerolog.New(io.Discard).
 			Hook(&Hook{
-				otelLogger: otelLogGlobal.GetLoggerProvider().Logger("test"),
+				otelLogger: rec.Logger("test"),
 			}).
 			WithContext(t.Context())
 
-		tracer := otel.Tracer(serviceName)
-		var parentSpan trace.Span
-		var childSpan trace.Span
-		var testErr error
-		func() {
-			ctx, parentSpan = tracer.Start(ctx, "segment.parent")
-			defer parentSpan.End()
-			func() {
-				ctx, childSpan = tracer.Start(ctx, "segment.child")
-				defer childSpan.End()
-
-				testErr = errors.WithMessage(errors.New("hook: an error occurred"), "hook: an error occurred in a lower down function")
-				log.Ctx(ctx).Error().Ctx(ctx).
-					Err(testErr).
-					Msg("test log")
-			}()
-		}()
-
-		time.Sleep(time.Second * 3)
-
-		events, _, err := stack.Seq.GetEvents(1, 10)
-		require.NoError(t, err, "must be able to get events from seq")
-
-		{ // check logs
-			require.Len(t, events, 1)
-			require.Len(t, events[0].Messages, 1)
-			assert.Equal(t, "test log", events[0].Messages[0].Text)
-
-			assert.Equal(t, "ERROR", events[0].Level)
-
-			assert.Equal(t, childSpan.SpanContext().TraceID().String(), events[0].TraceID)
-			assert.Equal(t, childSpan.SpanContext().SpanID().String(), events[0].SpanID)
-
-			require.Len(t, events[0].Properties, 3)
-			assert.Contains(t, events[0].Properties, seq.Property{
-				Name:  "level",
-				Value: "error",
-			})
-			assert.Equal(t, events[0].Exception, testErr.Error())
-		}
+		tracer := rec.TracerProvider().Tracer(serviceName)
+		ctx, parentSpan := tracer.Start(ctx, "segment.parent")
+		childCtx, childSpan := tracer.Start(ctx, "segment.child")
+
+		testErr := errors.WithMessage(errors.New("hook: an error occurred"), "hook: an error occurred in a lower down function")
+		log.Ctx(childCtx).Error().Ctx(childCtx).
+			Err(testErr).
+			Msg("test log")
+
+		childSpan.End()
+		parentSpan.End()
+
+		records := rec.Records()
+		require.Len(t, records, 1)
+		otelzlogtest.AssertRecord(t, records[0], otelLog.SeverityError, "test log",
+			otelLog.String("level", "error"),
+			otelLog.String("exception.message", testErr.Error()),
+		)
+		assert.Equal(t, 2, records[0].AttributesLen())
+		assert.Equal(t, childSpan.SpanContext(), records[0].SpanContext)
+
+		assert.Empty(t, rec.SpanEvents("segment.child"))
+		assert.Empty(t, rec.SpanEvents("segment.parent"))
 	})
 
 	t.Run("error with attaching to span", func(t *testing.T) {
-		stack := setupOTELStack(t)
+		rec := newRecorder(t)
 
-		ctx := log.
+		ctx := zerolog.New(io.Discard).
 			Hook(&Hook{
-				otelLogger:      otelLogGlobal.GetLoggerProvider().Logger("test"),
+				otelLogger:      rec.Logger("test"),
 				attachSpanError: true,
 				attachSpanEvent: true,
 			}).
 			WithContext(t.Context())
 
-		tracer := otel.Tracer(serviceName)
-		var parentSpan trace.Span
-		var childSpan trace.Span
-		var childCtx context.Context
-		var testErr error
-		func() {
-			ctx, parentSpan = tracer.Start(ctx, "segment.parent")
-			defer parentSpan.End()
-			func() {
-				childCtx, childSpan = tracer.Start(ctx, "segment.child")
-				defer childSpan.End()
-
-				testErr = errors.WithMessage(errors.New("hook: an error occurred"), "hook: an error occurred in a lower down function")
-				log.Ctx(childCtx).Error().Ctx(childCtx).
-					Err(testErr).
-					Msg("test log")
-			}()
-		}()
-
-		time.Sleep(time.Second * 3)
-
-		events, _, err := stack.Seq.GetEvents(1, 10)
-		require.NoError(t, err, "must be able to get events from seq")
-
-		traces, _, err := stack.Jaeger.GetTraces(1, 10, serviceName)
-		require.NoError(t, err, "must be able to get events from jaeger")
-
-		{ // check logs
-			require.Len(t, events, 1)
-			require.Len(t, events[0].Messages, 1)
-			assert.Equal(t, "test log", events[0].Messages[0].Text)
-
-			assert.Equal(t, "ERROR", events[0].Level)
-
-			assert.Equal(t, childSpan.SpanContext().TraceID().String(), events[0].TraceID)
-			assert.Equal(t, childSpan.SpanContext().SpanID().String(), events[0].SpanID)
-
-			require.Len(t, events[0].Properties, 3)
-			assert.Contains(t, events[0].Properties, seq.Property{
-				Name:  "level",
-				Value: "error",
-			})
-			assert.Equal(t, events[0].Exception, testErr.Error())
-		}
-
-		{ // check traces
-			require.Len(t, traces, 1)
-			require.Len(t, traces[0].Spans, 2)
-			spanMap := map[string]jaeger.Span{
-				traces[0].Spans[0].OperationName: traces[0].Spans[0],
-				traces[0].Spans[1].OperationName: traces[0].Spans[1],
-			}
-			assert.Contains(t, spanMap, "segment.parent")
-			assert.Contains(t, spanMap, "segment.child")
-
-			assert.Equal(t, parentSpan.SpanContext().TraceID().String(), spanMap["segment.parent"].TraceID)
-			assert.Equal(t, parentSpan.SpanContext().SpanID().String(), spanMap["segment.parent"].SpanID)
-			assert.Equal(t, childSpan.SpanContext().TraceID().String(), spanMap["segment.child"].TraceID)
-			assert.Equal(t, childSpan.SpanContext().SpanID().String(), spanMap["segment.child"].SpanID)
-
-			require.Len(t, spanMap["segment.child"].References, 1)
-			assert.Equal(t, jaeger.Reference{
-				RefType: "CHILD_OF",
-				TraceID: spanMap["segment.parent"].TraceID,
-				SpanID:  spanMap["segment.parent"].SpanID,
-			}, spanMap["segment.child"].References[0])
-
-			{ // child span
-				require.Len(t, spanMap["segment.child"].Tags, 2)
-				assert.Contains(t, spanMap["segment.child"].Tags, jaeger.KeyValue{
-					Key:   "otel.scope.name",
-					Type:  "string",
-					Value: serviceName,
-				})
-				// these shouldn't be present unless setSpanError=true in Hook{}
-				assert.NotContains(t, traces[0].Spans[0].Tags, jaeger.KeyValue{
-					Key:   "error",
-					Type:  "bool",
-					Value: true,
-				})
-				assert.NotContains(t, traces[0].Spans[0].Tags, jaeger.KeyValue{
-					Key:   string(semconv.OtelStatusCodeKey),
-					Type:  "string",
-					Value: "ERROR",
-				})
-
-				require.Len(t, spanMap["segment.child"].Logs, 1)
-				require.Len(t, spanMap["segment.child"].Logs[0].Fields, 4)
-				assert.Contains(t, spanMap["segment.child"].Logs[0].Fields, jaeger.KeyValue{
-					Key:   "event",
-					Type:  "string",
-					Value: "exception",
-				})
-				assert.Contains(t, spanMap["segment.child"].Logs[0].Fields, jaeger.KeyValue{
-					Key:   "exception.message",
-					Type:  "string",
-					Value: testErr.Error(),
-				})
-				assert.Contains(t, spanMap["segment.child"].Logs[0].Fields, jaeger.KeyValue{
-					Key:   "level",
-					Type:  "string",
-					Value: "error",
-				})
-			}
-
-			{ // parent span
-				require.Len(t, spanMap["segment.parent"].Tags, 2)
-				assert.Contains(t, spanMap["segment.parent"].Tags, jaeger.KeyValue{
-					Key:   "otel.scope.name",
-					Type:  "string",
-					Value: serviceName,
-				})
-			}
+		tracer := rec.TracerProvider().Tracer(serviceName)
+		ctx, parentSpan := tracer.Start(ctx, "segment.parent")
+		childCtx, childSpan := tracer.Start(ctx, "segment.child")
+
+		testErr := errors.WithMessage(errors.New("hook: an error occurred"), "hook: an error occurred in a lower down function")
+		log.Ctx(childCtx).Error().Ctx(childCtx).
+			Err(testErr).
+			Msg("test log")
+
+		childSpan.End()
+		parentSpan.End()
+
+		records := rec.Records()
+		require.Len(t, records, 1)
+		otelzlogtest.AssertRecord(t, records[0], otelLog.SeverityError, "test log",
+			otelLog.String("level", "error"),
+			otelLog.String("exception.message", testErr.Error()),
+		)
+		assert.Equal(t, childSpan.SpanContext(), records[0].SpanContext)
+
+		events := rec.SpanEvents("segment.child")
+		require.Len(t, events, 1)
+		assert.Equal(t, "test log", events[0].Name)
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("exception.message", testErr.Error()),
+			attribute.String("level", "error"),
+		}, events[0].Attributes)
+
+		// the status is left alone unless setSpanError=true in Hook{}
+		for _, span := range rec.Spans() {
+			assert.Equal(t, codes.Unset, span.Status().Code, span.Name())
 		}
+		assert.Empty(t, rec.SpanEvents("segment.parent"))
 	})
 
 	t.Run("error with stack from panic attaching to span", func(t *testing.T) {
-		stack := setupOTELStack(t)
+		rec := newRecorder(t)
 
-		ctx := log.
+		ctx := zerolog.New(io.Discard).
 			Hook(&Hook{
-				otelLogger:      otelLogGlobal.GetLoggerProvider().Logger("test"),
+				otelLogger:      rec.Logger("test"),
 				attachSpanError: true,
 				attachSpanEvent: true,
 			}).
 			WithContext(t.Context())
 
-		tracer := otel.Tracer(serviceName)
+		tracer := rec.TracerProvider().Tracer(serviceName)
 		var parentSpan trace.Span
 		var childSpan trace.Span
-		var childCtx context.Context
-
 		var testErr error
 		func() {
 			ctx, parentSpan = tracer.Start(ctx, "segment.parent")
@@ -246,157 +182,56 @@ func TestHook(t *testing.T) {
 				}
 			}()
 			func() {
-				childCtx, childSpan = tracer.Start(ctx, "segment.child")
+				childCtx, span := tracer.Start(ctx, "segment.child")
+				childSpan = span
 				defer childSpan.End()
 
 				log.Ctx(childCtx).Panic().Ctx(childCtx).Send()
 			}()
 		}()
 
-		time.Sleep(time.Second * 3)
-
-		events, _, err := stack.Seq.GetEvents(2, 10)
-		require.NoError(t, err, "must be able to get events from seq")
-
-		traces, _, err := stack.Jaeger.GetTraces(1, 10, serviceName)
-		require.NoError(t, err, "must be able to get events from jaeger")
+		records := rec.Records()
+		require.Len(t, records, 2)
+		{ // child
+			otelzlogtest.AssertRecord(t, records[0], otelLog.SeverityFatal, "",
+				otelLog.String("level", "panic"),
+			)
+			assert.Equal(t, childSpan.SpanContext(), records[0].SpanContext)
+		}
+		{ // parent
+			otelzlogtest.AssertRecord(t, records[1], otelLog.SeverityError, "",
+				otelLog.String("level", "error"),
+				otelLog.String("exception.message", testErr.Error()),
+				otelLog.String("exception.stacktrace", "stack-trace"),
+			)
+			assert.Equal(t, parentSpan.SpanContext(), records[1].SpanContext)
+		}
 
-		{ // check logs
+		{ // child span, with the hook's event and the SDK's panic exception
+			events := rec.SpanEvents("segment.child")
 			require.Len(t, events, 2)
-			require.Len(t, events[0].Messages, 1)
-			{ // parent
-				assert.Equal(t, "(No message)", events[0].Messages[0].Text)
-				assert.Equal(t, "ERROR", events[0].Level)
-
-				assert.Equal(t, parentSpan.SpanContext().TraceID().String(), events[0].TraceID)
-				assert.Equal(t, parentSpan.SpanContext().SpanID().String(), events[0].SpanID)
-
-				require.Len(t, events[0].Properties, 3)
-				assert.Contains(t, events[0].Properties, seq.Property{
-					Name:  "level",
-					Value: "error",
-				})
-				assert.Contains(t, events[0].Exception, testErr.Error())
-			}
-
-			{ // child
-				assert.Equal(t, "(No message)", events[1].Messages[0].Text)
-				assert.Equal(t, "FATAL", events[1].Level)
-
-				assert.Equal(t, childSpan.SpanContext().TraceID().String(), events[1].TraceID)
-				assert.Equal(t, childSpan.SpanContext().SpanID().String(), events[1].SpanID)
-
-				require.Len(t, events[1].Properties, 2)
-				assert.Contains(t, events[1].Properties, seq.Property{
-					Name:  "level",
-					Value: "panic",
-				})
-			}
+			assert.Contains(t, events[0].Attributes, attribute.String("level", "panic"))
+			assert.Equal(t, "exception", events[1].Name)
 		}
 
-		{ // check traces
-			require.Len(t, traces, 1)
-			require.Len(t, traces[0].Spans, 2)
-			spanMap := map[string]jaeger.Span{
-				traces[0].Spans[0].OperationName: traces[0].Spans[0],
-				traces[0].Spans[1].OperationName: traces[0].Spans[1],
-			}
-			assert.Contains(t, spanMap, "segment.parent")
-			assert.Contains(t, spanMap, "segment.child")
-
-			assert.Equal(t, parentSpan.SpanContext().TraceID().String(), spanMap["segment.parent"].TraceID)
-			assert.Equal(t, parentSpan.SpanContext().SpanID().String(), spanMap["segment.parent"].SpanID)
-			assert.Equal(t, childSpan.SpanContext().TraceID().String(), spanMap["segment.child"].TraceID)
-			assert.Equal(t, childSpan.SpanContext().SpanID().String(), spanMap["segment.child"].SpanID)
-
-			require.Len(t, spanMap["segment.child"].References, 1)
-			assert.Equal(t, jaeger.Reference{
-				RefType: "CHILD_OF",
-				TraceID: spanMap["segment.parent"].TraceID,
-				SpanID:  spanMap["segment.parent"].SpanID,
-			}, spanMap["segment.child"].References[0])
-
-			{ // child span
-				require.Len(t, spanMap["segment.child"].Tags, 2)
-				assert.Contains(t, spanMap["segment.child"].Tags, jaeger.KeyValue{
-					Key:   "otel.scope.name",
-					Type:  "string",
-					Value: serviceName,
-				})
-
-				require.Len(t, spanMap["segment.child"].Logs, 2)
-				for _, l := range spanMap["segment.child"].Logs {
-					switch len(l.Fields) {
-					case 2:
-						assert.Contains(t, l.Fields, jaeger.KeyValue{
-							Key:   "level",
-							Type:  "string",
-							Value: "panic",
-						})
-					case 3:
-						assert.Contains(t, l.Fields, jaeger.KeyValue{
-							Key:   "event",
-							Type:  "string",
-							Value: "exception",
-						})
-						assert.Contains(t, l.Fields, jaeger.KeyValue{
-							Key:   "exception.message",
-							Type:  "string",
-							Value: "",
-						})
-						assert.Contains(t, l.Fields, jaeger.KeyValue{
-							Key:   "exception.type",
-							Type:  "string",
-							Value: ".string",
-						})
-					default:
-						t.Fatalf("could not match field to expected")
-					}
-				}
-			}
-
-			{ // parent span
-				require.Len(t, spanMap["segment.parent"].Tags, 2)
-				assert.Contains(t, spanMap["segment.parent"].Tags, jaeger.KeyValue{
-					Key:   "otel.scope.name",
-					Type:  "string",
-					Value: serviceName,
-				})
-
-				require.Len(t, spanMap["segment.parent"].Logs, 1)
-				require.Len(t, spanMap["segment.parent"].Logs[0].Fields, 5)
-
-				assert.Contains(t, spanMap["segment.parent"].Logs[0].Fields, jaeger.KeyValue{
-					Key:   "event",
-					Type:  "string",
-					Value: "exception",
-				})
-				assert.Contains(t, spanMap["segment.parent"].Logs[0].Fields, jaeger.KeyValue{
-					Key:   "exception.message",
-					Type:  "string",
-					Value: testErr.Error(),
-				})
-				assert.Contains(t, spanMap["segment.parent"].Logs[0].Fields, jaeger.KeyValue{
-					Key:   "level",
-					Type:  "string",
-					Value: "error",
-				})
-				assert.Contains(t, spanMap["segment.parent"].Logs[0].Fields, jaeger.KeyValue{
-					Key:   "exception.stacktrace",
-					Type:  "string",
-					Value: "stack-trace",
-				})
-
-			}
+		{ // parent span
+			events := rec.SpanEvents("segment.parent")
+			require.Len(t, events, 1)
+			assert.Equal(t, "exception", events[0].Name)
+			assert.ElementsMatch(t, []attribute.KeyValue{
+				attribute.String("exception.message", testErr.Error()),
+				attribute.String("exception.stacktrace", "stack-trace"),
+				attribute.String("level", "error"),
+			}, events[0].Attributes)
 		}
 	})
 
 	t.Run("error with set span status", func(t *testing.T) {
-		stack := setupOTELStack(t)
+		rec := newRecorder(t)
 
-		ctx := log.
+		ctx := zerolog.New(io.Discard).
 			Hook(&Hook{
-				otelLogger:        otelLogGlobal.GetLoggerProvider().Logger("test"),
+				otelLogger:        rec.Logger("test"),
 				attachSpanError:   true,
 				attachSpanEvent:   true,
 				setSpanError:      true,
@@ -404,104 +239,363 @@ func TestHook(t *testing.T) {
 			}).
 			WithContext(t.Context())
 
-		tracer := otel.Tracer(serviceName)
-		var testErr error
-		func() {
-			ctx, span := tracer.Start(ctx, "segment.child")
-			defer span.End()
+		ctx, span := rec.TracerProvider().Tracer(serviceName).Start(ctx, "segment.child")
+		testErr := errors.New("hook: an error occurred")
+		log.Ctx(ctx).Error().Ctx(ctx).
+			Err(testErr).
+			Msg("test log")
+		span.End()
+
+		spans := rec.Spans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Equal(t, testErr.Error(), spans[0].Status().Description)
+	})
 
-			testErr = errors.New("hook: an error occurred")
-			log.Ctx(ctx).Error().Ctx(ctx).
-				Err(testErr).
-				Msg("test log")
-		}()
+	t.Run("source", func(t *testing.T) {
+		rec := newRecorder(t)
+		buf := new(bytes.Buffer)
+
+		ctx := zerolog.New(buf).With().CallerWithSkipFrameCount(0).Logger().
+			Hook(&Hook{
+				otelLogger: rec.Logger("test"),
+				source:     true,
+			}).WithContext(t.Context())
 
-		time.Sleep(time.Second * 3)
+		log.Ctx(ctx).Info().Ctx(ctx).
+			Msg("test log")
 
-		traces, _, err := stack.Jaeger.GetTraces(1, 10, serviceName)
-		require.NoError(t, err, "must be able to get events from jaeger")
+		m := map[string]any{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &m))
 
-		// time.Sleep(time.Second * 30)
+		filepath, line, err := extractSource(m["caller"].(string))
+		require.NoError(t, err)
 
-		{ // check traces
-			require.Len(t, traces, 1)
-			require.Len(t, traces[0].Spans, 1)
+		records := rec.Records()
+		require.Len(t, records, 1)
+		otelzlogtest.AssertRecord(t, records[0], otelLog.SeverityInfo, "test log",
+			otelLog.String("level", "info"),
+			otelLog.String("code.filepath", filepath),
+			otelLog.Int("code.lineno", line),
+		)
+		assert.Equal(t, 3, records[0].AttributesLen())
+	})
+}
 
-			require.Len(t, traces[0].Spans[0].Tags, 4)
-			assert.Contains(t, traces[0].Spans[0].Tags, jaeger.KeyValue{
-				Key:   "error",
-				Type:  "bool",
-				Value: true,
-			})
-			assert.Contains(t, traces[0].Spans[0].Tags, jaeger.KeyValue{
-				Key:   string(semconv.OtelStatusCodeKey),
-				Type:  "string",
-				Value: "ERROR",
-			})
+func TestHookFieldType(t *testing.T) {
+	h := Hook{fieldTypes: map[string]FieldType{
+		"elapsed":     FieldTypeTime,
+		"*elapsed":    FieldTypeDuration,
+		"*_at":        FieldTypeTime,
+		"*.duration":  FieldTypeDuration,
+		"*x.duration": FieldTypeTime,
+	}}
+
+	assert.Equal(t, FieldTypeTime, h.fieldType("elapsed"))
+	assert.Equal(t, FieldTypeDuration, h.fieldType("request_elapsed"))
+	assert.Equal(t, FieldTypeTime, h.fieldType("created_at"))
+	assert.Equal(t, FieldTypeDuration, h.fieldType("db.duration"))
+	assert.Equal(t, FieldTypeTime, h.fieldType("tx.duration"))
+	assert.Equal(t, FieldTypeDefault, h.fieldType("message"))
+}
+
+func TestHookFieldTypes(t *testing.T) {
+	recorder := newRecorder(t)
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger: recorder.Logger("test"),
+		fieldTypes: map[string]FieldType{"*_at": FieldTypeTime, "elapsed": FieldTypeDuration},
+	})
+
+	now := time.Unix(1700000000, 0)
+	logger.Info().Time("created_at", now).Dur("elapsed", time.Second).Msg("test log")
+
+	records := recorder.Records()
+	require.Len(t, records, 1)
+	attrs := records[0].Attributes()
+	assert.Equal(t, otelLog.Int64Value(now.UnixNano()), attrs["created_at"])
+	assert.Equal(t, otelLog.Int64Value(time.Second.Nanoseconds()), attrs["elapsed"])
+
+	t.Run("unix micro and nano", func(t *testing.T) {
+		defer func(format string) { zerolog.TimeFieldFormat = format }(zerolog.TimeFieldFormat)
+
+		now := time.Unix(1700000000, 123456789)
+		for _, format := range []string{zerolog.TimeFormatUnixMicro, zerolog.TimeFormatUnixNano} {
+			zerolog.TimeFieldFormat = format
+			logger.Info().Time("created_at", now).Msg("test log")
 		}
+
+		// nano timestamps are beyond the integers a float64 holds exactly
+		records := recorder.Records()
+		require.Len(t, records, 3)
+		assert.Equal(t, otelLog.Int64Value(now.Truncate(time.Microsecond).UnixNano()), records[1].Attributes()["created_at"])
+		assert.Equal(t, otelLog.Int64Value(now.UnixNano()), records[2].Attributes()["created_at"])
 	})
+}
 
-	t.Run("source", func(t *testing.T) {
-		stack := setupOTELStack(t)
+func TestHookBodyMode(t *testing.T) {
+	tests := []struct {
+		mode     BodyMode
+		expected otelLog.Value
+	}{
+		{
+			mode:     BodyMessage,
+			expected: otelLog.StringValue("test log"),
+		},
+		{
+			mode: BodyMap,
+			expected: otelLog.MapValue(
+				otelLog.String("message", "test log"),
+				otelLog.String("key", "value"),
+				otelLog.String("level", "info"),
+			),
+		},
+		{
+			mode:     BodyRawJSON,
+			expected: otelLog.StringValue(`{"level":"info","key":"value","message":"test log"}`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d", tt.mode), func(t *testing.T) {
+			recorder := newRecorder(t)
+			logger := zerolog.New(io.Discard).Hook(&Hook{
+				otelLogger: recorder.Logger("test"),
+				bodyMode:   tt.mode,
+			})
 
-		buf := new(bytes.Buffer)
+			logger.Info().Str("key", "value").Msg("test log")
 
-		ctx := log.With().CallerWithSkipFrameCount(0).Logger().
-			Output(buf).
-			Hook(&Hook{
-				otelLogger: otelLogGlobal.GetLoggerProvider().Logger("test"),
-				source:     true,
-			}).WithContext(t.Context())
+			records := recorder.Records()
+			require.Len(t, records, 1)
+			assert.Equal(t, tt.expected, records[0].Body())
+			assert.Equal(t, 2, records[0].AttributesLen())
+		})
+	}
+}
 
-		tracer := otel.Tracer(serviceName)
-		var parentSpan trace.Span
-		var childSpan trace.Span
-		func() {
-			ctx, parentSpan = tracer.Start(ctx, "segment.parent")
-			defer parentSpan.End()
-			func() {
-				ctx, childSpan = tracer.Start(ctx, "segment.child")
-				defer childSpan.End()
-				log.Ctx(ctx).Info().Ctx(ctx).
-					Msg("test log")
-			}()
-		}()
+func TestHookBodyRawJSONProcessed(t *testing.T) {
+	recorder := newRecorder(t)
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger: recorder.Logger("test"),
+		bodyMode:   BodyRawJSON,
+		processors: []AttributeProcessor{DropAttributes("password")},
+		logLimits:  attributeLimits{maxValueLen: 20},
+	})
 
-		time.Sleep(time.Second * 3)
+	logger.Info().Str("user", "bob").Str("password", "hunter2").Str("note", strings.Repeat("a", 100)).Msg("test log")
 
-		events, _, err := stack.Seq.GetEvents(1, 10)
-		require.NoError(t, err, "must be able to get events from seq")
+	records := recorder.Records()
+	require.Len(t, records, 1)
 
-		{ // check logs
-			require.Len(t, events, 1)
-			require.Len(t, events[0].Messages, 1)
-			assert.Equal(t, "test log", events[0].Messages[0].Text)
+	body := records[0].Body().AsString()
+	assert.NotContains(t, body, "hunter2")
+	assert.Equal(t, `{"level":"info","note":"`+strings.Repeat("a", 6)+truncatedMarker+`","user":"bob","message":"test log"}`, body)
+}
 
-			assert.Equal(t, "INFO", events[0].Level)
+func TestHookEventNameField(t *testing.T) {
+	recorder := newRecorder(t)
+	tracer := recorder.TracerProvider().Tracer("test")
 
-			assert.Equal(t, childSpan.SpanContext().TraceID().String(), events[0].TraceID)
-			assert.Equal(t, childSpan.SpanContext().SpanID().String(), events[0].SpanID)
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger:      recorder.Logger("test"),
+		attachSpanEvent: true,
+		eventNameField:  "event",
+	})
 
-			require.Len(t, events[0].Properties, 3)
-			assert.Contains(t, events[0].Properties, seq.Property{
-				Name:  "level",
-				Value: "info",
-			})
+	ctx, span := tracer.Start(t.Context(), "test.segment")
+	logger.Info().Ctx(ctx).Str("event", "user.login").Str("user", "bob").Msg("user logged in")
+	logger.Info().Ctx(ctx).Int("event", 10).Msg("not an event name")
+	span.End()
+
+	records := recorder.Records()
+	require.Len(t, records, 2)
+	assert.Equal(t, "user.login", records[0].EventName())
+	assert.NotContains(t, records[0].Attributes(), "event")
+	assert.Equal(t, "user logged in", records[0].Body().AsString())
+
+	assert.Empty(t, records[1].EventName())
+	assert.Equal(t, otelLog.Float64Value(10), records[1].Attributes()["event"])
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events(), 2)
+	assert.Equal(t, "user.login", spans[0].Events()[0].Name)
+	assert.Equal(t, "not an event name", spans[0].Events()[1].Name)
+}
 
-			m := map[string]any{}
-			err := json.Unmarshal(buf.Bytes(), &m)
-			require.NoError(t, err)
+func TestHookException(t *testing.T) {
+	var handled []error
 
-			filepath, line, err := extractSource(m["caller"].(string))
-			require.NoError(t, err)
+	recorder := newRecorder(t)
+	tracer := recorder.TracerProvider().Tracer("test")
 
-			assert.Contains(t, events[0].Properties, seq.Property{
-				Name: "code",
-				Value: map[string]any{
-					"filepath": filepath,
-					"lineno":   float64(line),
-				},
-			})
-		}
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger:      recorder.Logger("test"),
+		attachSpanEvent: true,
+		errorHandler:    func(err error) { handled = append(handled, err) },
 	})
+
+	ctx, span := tracer.Start(t.Context(), "test.segment")
+	logger.Error().Ctx(ctx).Str("event", "payment.failed").Err(errors.New("hook: an error occurred")).Msg("test log")
+	logger.Error().Ctx(ctx).Str("exception.message", "user message").Err(errors.New("hook: an error occurred")).Msg("test log")
+	span.End()
+
+	records := recorder.Records()
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "exception", records[0].EventName())
+	assert.Equal(t, map[string]otelLog.Value{
+		"event":             otelLog.StringValue("payment.failed"),
+		"exception.message": otelLog.StringValue("hook: an error occurred"),
+		"level":             otelLog.StringValue("error"),
+	}, records[0].Attributes())
+
+	assert.Equal(t, otelLog.StringValue("user message"), records[1].Attributes()["exception.message"])
+	require.Len(t, handled, 1)
+	assert.Contains(t, handled[0].Error(), `"exception.message"`)
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events(), 2)
+	assert.Equal(t, "test log", spans[0].Events()[0].Name)
+	assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("exception.message", "hook: an error occurred"))
+}
+
+func TestHookDecodeFallback(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       BodyMode
+		processors []AttributeProcessor
+		body       string
+		original   bool
+	}{
+		{name: "message", mode: BodyMessage, body: "test log", original: true},
+		{name: "raw json", mode: BodyRawJSON, body: `{"level":"info","a":"1","raw":{invalid,"b":"2","message":"test log"}`, original: false},
+		{name: "processors", mode: BodyMessage, processors: []AttributeProcessor{DropAttributes("b")}, body: "test log", original: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newRecorder(t)
+			logger := zerolog.New(io.Discard).Hook(&Hook{
+				otelLogger:   recorder.Logger("test"),
+				bodyMode:     tt.mode,
+				processors:   tt.processors,
+				errorHandler: func(error) {},
+			})
+
+			logger.Info().Str("a", "1").RawJSON("raw", []byte("{invalid")).Str("b", "2").Msg("test log")
+
+			records := recorder.Records()
+			require.Len(t, records, 1)
+			assert.Equal(t, tt.body, records[0].Body().AsString())
+
+			attrs := records[0].Attributes()
+			assert.Equal(t, otelLog.StringValue("1"), attrs["a"])
+			assert.Equal(t, otelLog.StringValue("info"), attrs["level"])
+			assert.NotContains(t, attrs, "b", "fields after the invalid one can't be decoded")
+			assert.Contains(t, attrs[decodeErrorKey].AsString(), "invalid character")
+
+			if tt.original {
+				assert.Equal(t, `{"level":"info","a":"1","raw":{invalid,"b":"2","message":"test log"}`, attrs[originalLineKey].AsString())
+			} else {
+				assert.NotContains(t, attrs, originalLineKey)
+			}
+		})
+	}
+}
+
+func TestHookStructuredFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		hook        *Hook
+		logAttrs    map[string]otelLog.Value
+		spanAttrs   []attribute.KeyValue
+		noLogAttrs  []string
+		noSpanAttrs []string
+	}{
+		{
+			name: "nested",
+			hook: &Hook{attachSpanEvent: true},
+			logAttrs: map[string]otelLog.Value{
+				"http": otelLog.MapValue(
+					otelLog.String("method", "GET"),
+					otelLog.Float64("status", 200),
+				),
+				"ids":  otelLog.SliceValue(otelLog.Float64Value(1), otelLog.Float64Value(2)),
+				"tags": otelLog.SliceValue(otelLog.StringValue("a"), otelLog.StringValue("b")),
+			},
+			spanAttrs: []attribute.KeyValue{
+				attribute.String("http", `{"method":"GET","status":200}`),
+				attribute.Float64Slice("ids", []float64{1, 2}),
+				attribute.StringSlice("tags", []string{"a", "b"}),
+			},
+		},
+		{
+			name: "flattened",
+			hook: &Hook{attachSpanEvent: true, flattenMaps: true},
+			logAttrs: map[string]otelLog.Value{
+				"http.method": otelLog.StringValue("GET"),
+				"http.status": otelLog.Float64Value(200),
+			},
+			spanAttrs: []attribute.KeyValue{
+				attribute.String("http.method", "GET"),
+				attribute.Float64("http.status", 200),
+			},
+			noLogAttrs:  []string{"http"},
+			noSpanAttrs: []string{"http"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newRecorder(t)
+			tracer := recorder.TracerProvider().Tracer("test")
+
+			tt.hook.otelLogger = recorder.Logger("test")
+			logger := zerolog.New(io.Discard).Hook(tt.hook)
+
+			ctx, span := tracer.Start(t.Context(), "test.segment")
+			logger.Info().Ctx(ctx).
+				Dict("http", zerolog.Dict().Str("method", "GET").Int("status", 200)).
+				Ints("ids", []int{1, 2}).
+				Array("tags", zerolog.Arr().Str("a").Str("b")).
+				Msg("test log")
+			span.End()
+
+			records := recorder.Records()
+			require.Len(t, records, 1)
+			attrs := records[0].Attributes()
+			for k, v := range tt.logAttrs {
+				assert.Equal(t, v, attrs[k], k)
+			}
+			for _, k := range tt.noLogAttrs {
+				assert.NotContains(t, attrs, k)
+			}
+
+			spans := recorder.Spans()
+			require.Len(t, spans, 1)
+			require.Len(t, spans[0].Events(), 1)
+			eventAttrs := spans[0].Events()[0].Attributes
+			for _, attr := range tt.spanAttrs {
+				assert.Contains(t, eventAttrs, attr)
+			}
+			for _, k := range tt.noSpanAttrs {
+				for _, attr := range eventAttrs {
+					assert.NotEqual(t, attribute.Key(k), attr.Key)
+				}
+			}
+		})
+	}
+}
+
+func TestHookRawJSONBytes(t *testing.T) {
+	recorder := newRecorder(t)
+	logger := zerolog.New(io.Discard).Hook(&Hook{otelLogger: recorder.Logger("test"), rawJSONBytes: true})
+
+	NewEvent(logger.Info().Ctx(t.Context())).RawJSON("array", []byte(`[1, 2]`)).Msg("test log")
+
+	records := recorder.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, otelLog.BytesValue([]byte(`[1, 2]`)), records[0].Attributes()["array"])
 }
@@ -0,0 +1,159 @@
+// Package otelzlog limits hold the limits that bound the size of the attributes
+// exported by the hook
+package otelzlog
+
+import (
+	"unicode/utf8"
+
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+const (
+	// truncatedMarker ends string and byte values that were cut to the maximum value length,
+	// and replaces values that were nested deeper than the maximum depth.
+	truncatedMarker = "...(truncated)"
+
+	// droppedAttributesKey is the attribute that holds the number of attributes that were
+	// dropped because of the maximum attribute count.
+	droppedAttributesKey = "otelzlog.dropped_attributes"
+)
+
+// attributeLimits bounds the attributes of either the otel log record or the span event.
+// A zero value for any of the limits disables that limit.
+type attributeLimits struct {
+	maxCount    int
+	maxValueLen int
+	maxDepth    int
+}
+
+func (l attributeLimits) enabled() bool {
+	return l.maxCount > 0 || l.maxValueLen > 0 || l.maxDepth > 0
+}
+
+// limitCounts counts what the attribute limits took out of the attributes.
+type limitCounts struct {
+	// dropped is the number of attributes, slice elements and map entries beyond the
+	// maximum count.
+	dropped int
+	// truncated is the number of values cut to the maximum value length or replaced
+	// beyond the maximum depth.
+	truncated int
+}
+
+func (c *limitCounts) add(other limitCounts) {
+	c.dropped += other.dropped
+	c.truncated += other.truncated
+}
+
+// apply truncates the attributes to the limits. Attributes beyond the maximum count, as
+// well as elements beyond the maximum count in slices and maps, are dropped and counted
+// in an "otelzlog.dropped_attributes" attribute. The number dropped and truncated is
+// also returned.
+func (l attributeLimits) apply(attrs []otelLog.KeyValue) ([]otelLog.KeyValue, limitCounts) {
+	if !l.enabled() {
+		return attrs, limitCounts{}
+	}
+
+	var counts limitCounts
+	if l.maxCount > 0 && len(attrs) > l.maxCount {
+		counts.dropped = len(attrs) - l.maxCount
+		attrs = attrs[:l.maxCount]
+	}
+
+	limited := make([]otelLog.KeyValue, 0, len(attrs)+1)
+	for _, kv := range attrs {
+		var c limitCounts
+		kv.Value, c = l.limitValue(kv.Value, 0)
+		counts.add(c)
+		limited = append(limited, kv)
+	}
+
+	if counts.dropped > 0 {
+		limited = append(limited, otelLog.Int(droppedAttributesKey, counts.dropped))
+	}
+
+	return limited, counts
+}
+
+// limitValue truncates v, which is nested at depth, to the limits and counts the slice
+// or map elements that were dropped, and the values that were truncated, along the way.
+func (l attributeLimits) limitValue(v otelLog.Value, depth int) (otelLog.Value, limitCounts) {
+	switch v.Kind() {
+	case otelLog.KindString:
+		s, truncated := l.truncateString(v.AsString())
+		if !truncated {
+			return v, limitCounts{}
+		}
+		return otelLog.StringValue(s), limitCounts{truncated: 1}
+
+	case otelLog.KindBytes:
+		b := v.AsBytes()
+		if l.maxValueLen <= 0 || len(b) <= l.maxValueLen {
+			return v, limitCounts{}
+		}
+
+		cut := max(l.maxValueLen-len(truncatedMarker), 0)
+		marker := truncatedMarker[:l.maxValueLen-cut]
+		return otelLog.BytesValue(append(b[:cut:cut], marker...)), limitCounts{truncated: 1}
+
+	case otelLog.KindSlice:
+		if l.maxDepth > 0 && depth >= l.maxDepth {
+			return otelLog.StringValue(truncatedMarker), limitCounts{truncated: 1}
+		}
+
+		items := v.AsSlice()
+		var counts limitCounts
+		if l.maxCount > 0 && len(items) > l.maxCount {
+			counts.dropped = len(items) - l.maxCount
+			items = items[:l.maxCount]
+		}
+
+		limited := make([]otelLog.Value, 0, len(items))
+		for _, item := range items {
+			item, c := l.limitValue(item, depth+1)
+			counts.add(c)
+			limited = append(limited, item)
+		}
+		return otelLog.SliceValue(limited...), counts
+
+	case otelLog.KindMap:
+		if l.maxDepth > 0 && depth >= l.maxDepth {
+			return otelLog.StringValue(truncatedMarker), limitCounts{truncated: 1}
+		}
+
+		kvs := v.AsMap()
+		var counts limitCounts
+		if l.maxCount > 0 && len(kvs) > l.maxCount {
+			counts.dropped = len(kvs) - l.maxCount
+			kvs = kvs[:l.maxCount]
+		}
+
+		limited := make([]otelLog.KeyValue, 0, len(kvs))
+		for _, kv := range kvs {
+			var c limitCounts
+			kv.Value, c = l.limitValue(kv.Value, depth+1)
+			counts.add(c)
+			limited = append(limited, kv)
+		}
+		return otelLog.MapValue(limited...), counts
+	}
+
+	return v, limitCounts{}
+}
+
+// truncateString cuts s to the maximum value length without splitting a multi-byte
+// character, and marks it as truncated. The marker counts towards the length, and is
+// itself cut if the maximum value length is shorter than it.
+func (l attributeLimits) truncateString(s string) (string, bool) {
+	if l.maxValueLen <= 0 || len(s) <= l.maxValueLen {
+		return s, false
+	}
+
+	cut := max(l.maxValueLen-len(truncatedMarker), 0)
+	marker := truncatedMarker[:l.maxValueLen-cut]
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return s[:cut] + marker, true
+}
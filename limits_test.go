@@ -0,0 +1,178 @@
+package otelzlog
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+func TestAttributeLimits(t *testing.T) {
+	nested := otelLog.MapValue(
+		otelLog.Map("a", otelLog.Map("b", otelLog.String("c", "d"))),
+	)
+
+	tests := []struct {
+		name      string
+		limits    attributeLimits
+		input     []otelLog.KeyValue
+		expected  []otelLog.KeyValue
+		truncated int
+	}{
+		{
+			name:     "disabled",
+			limits:   attributeLimits{},
+			input:    []otelLog.KeyValue{otelLog.String("a", "abcdef")},
+			expected: []otelLog.KeyValue{otelLog.String("a", "abcdef")},
+		},
+		{
+			name:   "count",
+			limits: attributeLimits{maxCount: 2},
+			input: []otelLog.KeyValue{
+				otelLog.Int("a", 1),
+				otelLog.Int("b", 2),
+				otelLog.Int("c", 3),
+			},
+			expected: []otelLog.KeyValue{
+				otelLog.Int("a", 1),
+				otelLog.Int("b", 2),
+				otelLog.Int(droppedAttributesKey, 1),
+			},
+		},
+		{
+			name:   "slice count",
+			limits: attributeLimits{maxCount: 2},
+			input: []otelLog.KeyValue{
+				otelLog.Slice("a", otelLog.IntValue(1), otelLog.IntValue(2), otelLog.IntValue(3)),
+			},
+			expected: []otelLog.KeyValue{
+				otelLog.Slice("a", otelLog.IntValue(1), otelLog.IntValue(2)),
+				otelLog.Int(droppedAttributesKey, 1),
+			},
+		},
+		{
+			name:   "value length",
+			limits: attributeLimits{maxValueLen: 17},
+			input: []otelLog.KeyValue{
+				otelLog.String("a", "abcdefghijklmnopqrstuvwxyz"),
+				otelLog.String("b", "abc"),
+				otelLog.Bytes("c", []byte("abcdefghijklmnopqrstuvwxyz")),
+			},
+			expected: []otelLog.KeyValue{
+				otelLog.String("a", "abc"+truncatedMarker),
+				otelLog.String("b", "abc"),
+				otelLog.Bytes("c", []byte("abc"+truncatedMarker)),
+			},
+			truncated: 2,
+		},
+		{
+			name:   "value length shorter than marker",
+			limits: attributeLimits{maxValueLen: 5},
+			input: []otelLog.KeyValue{
+				otelLog.String("a", "abcdefgh"),
+				otelLog.Bytes("b", []byte("abcdefgh")),
+			},
+			expected: []otelLog.KeyValue{
+				otelLog.String("a", truncatedMarker[:5]),
+				otelLog.Bytes("b", []byte(truncatedMarker[:5])),
+			},
+			truncated: 2,
+		},
+		{
+			name:      "value length multi-byte",
+			limits:    attributeLimits{maxValueLen: 17},
+			input:     []otelLog.KeyValue{otelLog.String("a", "éééééééééé")},
+			expected:  []otelLog.KeyValue{otelLog.String("a", "é"+truncatedMarker)},
+			truncated: 1,
+		},
+		{
+			name:   "depth",
+			limits: attributeLimits{maxDepth: 2},
+			input:  []otelLog.KeyValue{{Key: "a", Value: nested}},
+			expected: []otelLog.KeyValue{
+				otelLog.Map("a", otelLog.Map("a", otelLog.String("b", truncatedMarker))),
+			},
+			truncated: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limited, counts := tt.limits.apply(tt.input)
+			assert.Equal(t, tt.expected, limited)
+			assert.Equal(t, tt.truncated, counts.truncated)
+			if counts.dropped > 0 {
+				assert.Contains(t, limited, otelLog.Int(droppedAttributesKey, counts.dropped))
+			}
+		})
+	}
+}
+
+func TestHookAttributeLimits(t *testing.T) {
+	recorder := newRecorder(t)
+	tracer := recorder.TracerProvider().Tracer("test")
+
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger:      recorder.Logger("test"),
+		attachSpanEvent: true,
+		logLimits:       attributeLimits{maxValueLen: 18},
+		spanLimits:      attributeLimits{maxCount: 1},
+	})
+
+	ctx, span := tracer.Start(t.Context(), "test.segment")
+	logger.Info().Ctx(ctx).Str("a", "abcdefghijklmnopqrstuvwxyz").Str("b", "value").Msg("test log")
+	span.End()
+
+	records := recorder.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string]otelLog.Value{
+		"a":     otelLog.StringValue("abcd" + truncatedMarker),
+		"b":     otelLog.StringValue("value"),
+		"level": otelLog.StringValue("info"),
+	}, records[0].Attributes())
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("a", "abcdefghijklmnopqrstuvwxyz"),
+		attribute.Int64(droppedAttributesKey, 2),
+	}, spans[0].Events()[0].Attributes)
+}
+
+func TestHookAttributeLimitsKeepException(t *testing.T) {
+	recorder := newRecorder(t)
+	tracer := recorder.TracerProvider().Tracer("test")
+
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger:      recorder.Logger("test"),
+		attachSpanEvent: true,
+		logLimits:       attributeLimits{maxCount: 1},
+		spanLimits:      attributeLimits{maxCount: 1},
+	})
+
+	ctx, span := tracer.Start(t.Context(), "test.segment")
+	logger.Error().Ctx(ctx).Str("a", "value").Err(errors.New("limits: an error occurred")).Msg("test log")
+	span.End()
+
+	// the attributes derived by the hook come first, so user fields are dropped first
+	records := recorder.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string]otelLog.Value{
+		"exception.message":  otelLog.StringValue("limits: an error occurred"),
+		droppedAttributesKey: otelLog.Int64Value(2),
+	}, records[0].Attributes())
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("exception.message", "limits: an error occurred"),
+		attribute.Int64(droppedAttributesKey, 2),
+	}, spans[0].Events()[0].Attributes)
+}
@@ -0,0 +1,225 @@
+// Package otelzlog logr holds the logr sink that writes through the zerolog
+// logger and hook
+package otelzlog
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// logrNameKey is the field that holds the name of the logr logger, with the
+	// names added by WithName joined by "/".
+	logrNameKey = "logger"
+
+	// logrNoValue is the value of a key without a value in a key/value list.
+	logrNoValue = "<no-value>"
+)
+
+// LogSink is a logr.LogSink that writes through the zerolog logger of the context
+// it was created with, and so through the same [Hook] as the rest of the application's
+// logs: controller-runtime and client-go logs get the same severity mapping, span
+// events, span status and attribute conversion as `log.Ctx(ctx).Info().Ctx(ctx)`.
+//
+// V-levels are mapped to zerolog levels, V(0) being info, V(1) debug and V(2) and
+// above trace.
+type LogSink struct {
+	ctx       context.Context
+	callDepth int
+
+	name   string
+	values []any
+}
+
+var (
+	_ logr.LogSink          = (*LogSink)(nil)
+	_ logr.CallDepthLogSink = (*LogSink)(nil)
+)
+
+// NewLogSink creates a [LogSink]. ctx is the context returned by [New], or one derived
+// from it, whose logger is used and whose span the records are correlated with.
+func NewLogSink(ctx context.Context) *LogSink {
+	return &LogSink{ctx: ctx}
+}
+
+// NewLogr creates a logr.Logger backed by a [LogSink] for ctx.
+func NewLogr(ctx context.Context) logr.Logger {
+	return logr.New(NewLogSink(ctx))
+}
+
+// Init receives the call depth of the logr.Logger, used for the caller.
+func (s *LogSink) Init(info logr.RuntimeInfo) {
+	s.callDepth = info.CallDepth
+}
+
+// Enabled reports whether the V-level is enabled for the zerolog logger.
+func (s *LogSink) Enabled(level int) bool {
+	zlevel := convertLogrLevel(level)
+	return zlevel >= zerolog.Ctx(s.ctx).GetLevel() && zlevel >= zerolog.GlobalLevel()
+}
+
+// Info writes a non-error message at the zerolog level of the V-level.
+func (s *LogSink) Info(level int, msg string, keysAndValues ...any) {
+	s.write(convertLogrLevel(level), nil, msg, keysAndValues)
+}
+
+// Error writes an error message at the error level, with err as the error field.
+func (s *LogSink) Error(err error, msg string, keysAndValues ...any) {
+	s.write(zerolog.ErrorLevel, err, msg, keysAndValues)
+}
+
+// WithValues returns a sink that adds keysAndValues to every message.
+func (s *LogSink) WithValues(keysAndValues ...any) logr.LogSink {
+	if len(keysAndValues) == 0 {
+		return s
+	}
+
+	s2 := *s
+	s2.values = append(append([]any{}, s.values...), keysAndValues...)
+	if len(s2.values)%2 != 0 {
+		s2.values = append(s2.values, logrNoValue)
+	}
+
+	return &s2
+}
+
+// WithName returns a sink whose name has name appended, separated by "/".
+func (s *LogSink) WithName(name string) logr.LogSink {
+	s2 := *s
+	if s.name == "" {
+		s2.name = name
+	} else {
+		s2.name = s.name + "/" + name
+	}
+
+	return &s2
+}
+
+// WithCallDepth returns a sink that skips depth more frames when finding the caller.
+func (s *LogSink) WithCallDepth(depth int) logr.LogSink {
+	s2 := *s
+	s2.callDepth += depth
+
+	return &s2
+}
+
+// write sends an event with the caller, name and values of the sink, then err and
+// keysAndValues. It must only be called from the sink's logging methods, as the caller
+// is found from the call depth relative to them.
+func (s *LogSink) write(level zerolog.Level, err error, msg string, keysAndValues []any) {
+	zevent := zerolog.Ctx(s.ctx).WithLevel(level)
+	if !zevent.Enabled() {
+		return
+	}
+	event := NewEvent(zevent.Ctx(s.ctx))
+
+	if hook := hookFromContext(s.ctx); hook != nil && hook.source {
+		// Skip write, the sink's logging method and the logr.Logger method. The hook's
+		// own caller would point into the sink, so none is better than that.
+		if _, file, line, ok := runtime.Caller(s.callDepth + 2); ok {
+			event.Caller(file, line)
+		} else {
+			event.omitCaller()
+		}
+	}
+
+	if err != nil {
+		event.Err(err)
+	}
+
+	if s.name != "" {
+		event.Str(logrNameKey, s.name)
+	}
+
+	addLogrValues(event, s.values)
+	addLogrValues(event, keysAndValues)
+
+	event.Msg(msg)
+}
+
+// convertLogrLevel converts a logr V-level into a zerolog.Level. V(0) is info, V(1) is
+// debug and anything above is trace. Negative levels are not allowed by logr and are
+// treated as info.
+func convertLogrLevel(level int) zerolog.Level {
+	switch {
+	case level <= 0:
+		return zerolog.InfoLevel
+	case level == 1:
+		return zerolog.DebugLevel
+	default:
+		return zerolog.TraceLevel
+	}
+}
+
+// addLogrValues adds the key/value pairs to the event with their native types. Keys
+// that aren't strings are formatted, and a trailing key without a value gets
+// "<no-value>".
+func addLogrValues(e *Event, keysAndValues []any) {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+
+		var value any = logrNoValue
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+
+		addLogrValue(e, key, value)
+	}
+}
+
+func addLogrValue(e *Event, key string, value any) {
+	if marshaler, ok := value.(logr.Marshaler); ok {
+		value = marshaler.MarshalLog()
+	}
+
+	switch val := value.(type) {
+	case string:
+		e.Str(key, val)
+	case int:
+		e.Int64(key, int64(val))
+	case int8:
+		e.Int64(key, int64(val))
+	case int16:
+		e.Int64(key, int64(val))
+	case int32:
+		e.Int64(key, int64(val))
+	case int64:
+		e.Int64(key, val)
+	case uint:
+		e.Uint64(key, uint64(val))
+	case uint8:
+		e.Uint64(key, uint64(val))
+	case uint16:
+		e.Uint64(key, uint64(val))
+	case uint32:
+		e.Uint64(key, uint64(val))
+	case uint64:
+		e.Uint64(key, val)
+	case float32:
+		e.Float64(key, float64(val))
+	case float64:
+		e.Float64(key, val)
+	case bool:
+		e.Bool(key, val)
+	case time.Duration:
+		e.Dur(key, val)
+	case time.Time:
+		e.Time(key, val)
+	case error:
+		e.Str(key, val.Error())
+	case fmt.Stringer:
+		e.Str(key, val.String())
+	case []byte:
+		e.Bytes(key, val)
+	default:
+		e.Interface(key, val)
+	}
+}
@@ -0,0 +1,178 @@
+package otelzlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adreasnow/otelzlog/otelzlogtest"
+	"github.com/go-logr/logr"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+func setupLogr(t *testing.T, options ...Option) (context.Context, *otelzlogtest.Recorder) {
+	t.Helper()
+
+	rec := newRecorder(t)
+	ctx := New(t.Context(), "test", append([]Option{
+		WithLoggerProvider(rec),
+		WithTracerProvider(rec.TracerProvider()),
+		WithWriter(io.Discard),
+	}, options...)...)
+
+	return ctx, rec
+}
+
+type logrMarshaler struct{ id int }
+
+func (m logrMarshaler) MarshalLog() any { return "id-" + strconv.Itoa(m.id) }
+
+func TestLogSink(t *testing.T) {
+	ctx, rec := setupLogr(t, WithAttachSpanEvent(true), WithSetSpanErrorStatus(true, zerolog.ErrorLevel))
+
+	ctx, span := rec.TracerProvider().Tracer("test").Start(ctx, "test.segment")
+	logger := NewLogr(ctx).WithName("controller").WithValues("namespace", "default").WithName("pod")
+	logger.Error(errors.New("connection refused"), "reconcile failed",
+		"attempt", 3,
+		"requeue", true,
+		"elapsed", time.Second,
+		"object", logrMarshaler{id: 10},
+	)
+	span.End()
+
+	records := rec.Records()
+	require.Len(t, records, 1)
+	otelzlogtest.AssertRecord(t, records[0], otelLog.SeverityError, "reconcile failed",
+		otelLog.String("level", "error"),
+		otelLog.String("logger", "controller/pod"),
+		otelLog.String("namespace", "default"),
+		otelLog.String("exception.message", "connection refused"),
+		otelLog.Int64("attempt", 3),
+		otelLog.Bool("requeue", true),
+		otelLog.Int64("elapsed", time.Second.Nanoseconds()),
+		otelLog.String("object", "id-10"),
+	)
+	assert.Equal(t, span.SpanContext(), records[0].SpanContext)
+
+	events := rec.SpanEvents("test.segment")
+	require.Len(t, events, 1)
+	assert.Contains(t, events[0].Attributes, attribute.String("logger", "controller/pod"))
+
+	require.Len(t, rec.Spans(), 1)
+	assert.Equal(t, "connection refused", rec.Spans()[0].Status().Description)
+}
+
+func TestLogSinkLevels(t *testing.T) {
+	tests := []struct {
+		v        int
+		expected otelLog.Severity
+	}{
+		{0, otelLog.SeverityInfo},
+		{1, otelLog.SeverityDebug},
+		{2, otelLog.SeverityTrace},
+		{5, otelLog.SeverityTrace},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.v), func(t *testing.T) {
+			ctx, rec := setupLogr(t)
+			ctx = zerolog.Ctx(ctx).Level(zerolog.TraceLevel).WithContext(ctx)
+
+			NewLogr(ctx).V(tt.v).Info("test log")
+
+			records := rec.Records()
+			require.Len(t, records, 1)
+			assert.Equal(t, tt.expected, records[0].Severity())
+		})
+	}
+}
+
+func TestLogSinkEnabled(t *testing.T) {
+	ctx, rec := setupLogr(t)
+	ctx = zerolog.Ctx(ctx).Level(zerolog.DebugLevel).WithContext(ctx)
+	logger := NewLogr(ctx)
+
+	assert.True(t, logger.V(1).Enabled())
+	assert.False(t, logger.V(2).Enabled())
+
+	logger.V(2).Info("test log")
+	assert.Empty(t, rec.Records())
+
+	quiet := NewLogr(zerolog.Ctx(ctx).Level(zerolog.WarnLevel).WithContext(ctx))
+	quiet.Info("test log")
+	assert.Empty(t, rec.Records())
+
+	quiet.Error(nil, "test log")
+	assert.Len(t, rec.Records(), 1)
+}
+
+func TestLogSinkSource(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ctx, rec := setupLogr(t, WithSource(true, 0), WithWriter(buf))
+	logger := NewLogr(ctx)
+
+	_, file, line, _ := runtime.Caller(0)
+	logger.Info("test log")
+	logrHelper(logger)
+
+	records := rec.Records()
+	require.Len(t, records, 2)
+	otelzlogtest.AssertRecord(t, records[0], otelLog.SeverityInfo, "test log",
+		otelLog.String("code.filepath", file),
+		otelLog.Int("code.lineno", line+1),
+	)
+	otelzlogtest.AssertRecord(t, records[1], otelLog.SeverityInfo, "helper log",
+		otelLog.String("code.filepath", file),
+		otelLog.Int("code.lineno", line+2),
+	)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	for i, l := range lines {
+		assert.Equal(t, 1, strings.Count(l, `"caller"`))
+		assert.Contains(t, l, strconv.Quote(file+":"+strconv.Itoa(line+i+1)))
+	}
+}
+
+func TestLogSinkSourceUnknown(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ctx, rec := setupLogr(t, WithSource(true, 0), WithWriter(buf))
+
+	// a call depth past the top of the stack leaves the caller unknown
+	NewLogr(ctx).WithCallDepth(1000).Info("test log")
+
+	records := rec.Records()
+	require.Len(t, records, 1)
+	assert.NotContains(t, records[0].Attributes(), "code.filepath")
+	assert.NotContains(t, buf.String(), `"caller"`)
+}
+
+func logrHelper(logger logr.Logger) {
+	logger.WithCallDepth(1).Info("helper log")
+}
+
+func TestAddLogrValues(t *testing.T) {
+	recorder := newRecorder(t)
+	logger := zerolog.New(io.Discard).Hook(&Hook{otelLogger: recorder.Logger("test")})
+
+	event := NewEvent(logger.Info().Ctx(t.Context()))
+	addLogrValues(event, []any{1, "a", "b"})
+	event.Msg("test message")
+
+	records := recorder.Records()
+	require.Len(t, records, 1)
+	attrs := records[0].Attributes()
+
+	assert.Equal(t, otelLog.StringValue("a"), attrs["1"])
+	assert.Equal(t, otelLog.StringValue("<no-value>"), attrs["b"])
+}
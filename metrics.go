@@ -0,0 +1,104 @@
+// Package otelzlog metrics hold the counters that are derived from the zerolog
+// events passing through the hook
+package otelzlog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+const (
+	// recordsMetricName counts the log records handled by the hook.
+	recordsMetricName = "log.records"
+	// exceptionsMetricName counts the log records that carry an error.
+	exceptionsMetricName = "exceptions"
+
+	// unknownExceptionType is the exception type of errors that were written straight to
+	// zerolog, which only keeps their message.
+	unknownExceptionType = "unknown"
+)
+
+// logMetrics holds the counters that the hook increments for every log record.
+type logMetrics struct {
+	records    metric.Int64Counter
+	exceptions metric.Int64Counter
+
+	loggerName string
+	fields     []string
+}
+
+// newLogMetrics creates the counters from the meter provider. fields are the names of the
+// zerolog fields that are added as attributes to the counters.
+func newLogMetrics(provider metric.MeterProvider, loggerName string, fields []string) (*logMetrics, error) {
+	meter := provider.Meter(instrumentationName)
+
+	records, err := meter.Int64Counter(recordsMetricName,
+		metric.WithDescription("The number of log records, by severity."),
+		metric.WithUnit("{record}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	exceptions, err := meter.Int64Counter(exceptionsMetricName,
+		metric.WithDescription("The number of log records carrying an error, by exception type."),
+		metric.WithUnit("{exception}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logMetrics{
+		records:    records,
+		exceptions: exceptions,
+		loggerName: loggerName,
+		fields:     fields,
+	}, nil
+}
+
+// record increments the counters for a log record. The span in ctx is used by the
+// metric SDK to link exemplars to the current trace.
+func (m *logMetrics) record(ctx context.Context, level zerolog.Level, logErr error, logData map[string]any) {
+	if m == nil {
+		return
+	}
+
+	_, severityText := convertLevel(level)
+
+	attrs := make([]attribute.KeyValue, 0, len(m.fields)+2)
+	attrs = append(attrs,
+		attribute.String("severity_text", severityText),
+		attribute.String("logger.name", m.loggerName),
+	)
+	for _, field := range m.fields {
+		if v, ok := logData[field]; ok {
+			attrs = append(attrs, attribute.KeyValue{
+				Key:   attribute.Key(field),
+				Value: convertLogToAttribute(convertAttribute(v)),
+			})
+		}
+	}
+
+	m.records.Add(ctx, 1, metric.WithAttributes(attrs...))
+
+	if logErr != nil {
+		m.exceptions.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("logger.name", m.loggerName),
+			semconv.ExceptionTypeKey.String(exceptionType(logErr)),
+		))
+	}
+}
+
+// exceptionType returns the go type of err, or "unknown" if the error was rebuilt from
+// its message.
+func exceptionType(err error) string {
+	if _, ok := err.(messageError); ok {
+		return unknownExceptionType
+	}
+	return fmt.Sprintf("%T", err)
+}
@@ -0,0 +1,137 @@
+package otelzlog
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+)
+
+func TestHookMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	metrics, err := newLogMetrics(provider, "test", []string{"service"})
+	require.NoError(t, err)
+
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger: newRecorder(t).Logger("test"),
+		metrics:    metrics,
+	})
+
+	logger.Info().Str("service", "api").Msg("test log")
+	logger.Info().Str("service", "api").Msg("test log")
+	logger.Error().Err(errors.New("metrics: an error occurred")).Msg("test log")
+	NewEvent(logger.Error()).Err(errors.New("metrics: an error occurred")).Msg("test log")
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	assert.Equal(t, instrumentationName, rm.ScopeMetrics[0].Scope.Name)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 2)
+
+	metricdatatest.AssertEqual(t, metricdata.Metrics{
+		Name:        recordsMetricName,
+		Description: "The number of log records, by severity.",
+		Unit:        "{record}",
+		Data: metricdata.Sum[int64]{
+			Temporality: metricdata.CumulativeTemporality,
+			IsMonotonic: true,
+			DataPoints: []metricdata.DataPoint[int64]{
+				{
+					Attributes: attribute.NewSet(
+						attribute.String("severity_text", "INFO"),
+						attribute.String("logger.name", "test"),
+						attribute.String("service", "api"),
+					),
+					Value: 2,
+				},
+				{
+					Attributes: attribute.NewSet(
+						attribute.String("severity_text", "ERROR"),
+						attribute.String("logger.name", "test"),
+					),
+					Value: 2,
+				},
+			},
+		},
+	}, rm.ScopeMetrics[0].Metrics[0], metricdatatest.IgnoreTimestamp())
+
+	metricdatatest.AssertEqual(t, metricdata.Metrics{
+		Name:        exceptionsMetricName,
+		Description: "The number of log records carrying an error, by exception type.",
+		Unit:        "{exception}",
+		Data: metricdata.Sum[int64]{
+			Temporality: metricdata.CumulativeTemporality,
+			IsMonotonic: true,
+			DataPoints: []metricdata.DataPoint[int64]{
+				{
+					Attributes: attribute.NewSet(
+						attribute.String("logger.name", "test"),
+						attribute.String("exception.type", unknownExceptionType),
+					),
+					Value: 1,
+				},
+				{
+					Attributes: attribute.NewSet(
+						attribute.String("logger.name", "test"),
+						attribute.String("exception.type", "*errors.errorString"),
+					),
+					Value: 1,
+				},
+			},
+		},
+	}, rm.ScopeMetrics[0].Metrics[1], metricdatatest.IgnoreTimestamp())
+}
+
+func TestHookMetricsSampledOut(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	metrics, err := newLogMetrics(provider, "test", nil)
+	require.NoError(t, err)
+
+	recorder := newRecorder(t)
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger: recorder.Logger("test"),
+		metrics:    metrics,
+		sampler:    &traceSampler{rate: 0},
+	})
+
+	// debug events outside of a span are sampled out of the export, but still counted
+	logger.Debug().Ctx(t.Context()).Msg("test log")
+	logger.Debug().Ctx(t.Context()).Err(errors.New("metrics: an error occurred")).Msg("test log")
+	assert.Empty(t, recorder.Records())
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 2)
+
+	metricdatatest.AssertEqual(t, metricdata.Metrics{
+		Name:        recordsMetricName,
+		Description: "The number of log records, by severity.",
+		Unit:        "{record}",
+		Data: metricdata.Sum[int64]{
+			Temporality: metricdata.CumulativeTemporality,
+			IsMonotonic: true,
+			DataPoints: []metricdata.DataPoint[int64]{
+				{
+					Attributes: attribute.NewSet(
+						attribute.String("severity_text", "DEBUG"),
+						attribute.String("logger.name", "test"),
+					),
+					Value: 2,
+				},
+			},
+		},
+	}, rm.ScopeMetrics[0].Metrics[0], metricdatatest.IgnoreTimestamp())
+	assert.Equal(t, exceptionsMetricName, rm.ScopeMetrics[0].Metrics[1].Name)
+}
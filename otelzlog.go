@@ -5,16 +5,28 @@ import (
 	"context"
 	"io"
 	"runtime"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	otelLog "go.opentelemetry.io/otel/log"
 	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type config struct {
-	provider otelLog.LoggerProvider
+	provider       otelLog.LoggerProvider
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	metricFields   []string
+
+	// globalProvider and globalTracerProvider are set if the providers were taken
+	// from the otel globals
+	globalProvider       bool
+	globalTracerProvider bool
 
 	source       bool
 	sourceOffset int
@@ -23,9 +35,44 @@ type config struct {
 	attachSpanEvent   bool
 	setSpanError      bool
 	setSpanErrorLevel zerolog.Level
+	spanStatusFormat  SpanStatusFormatter
+	setSpanOk         bool
+	setSpanOkLevel    zerolog.Level
+	setSpanOkField    string
+	spanErrorPolicy   SpanErrorPolicy
+	orphanSpans       bool
+	orphanSpanLevel   zerolog.Level
+
+	sampler     *traceSampler
+	dedupWindow time.Duration
+
+	async           bool
+	asyncQueueSize  int
+	asyncDropPolicy DropPolicy
+
+	flushOnFatal bool
+	flushTimeout time.Duration
+
+	errorHandler func(error)
+
+	flattenMaps  bool
+	rawJSONBytes bool
+
+	fieldTypes map[string]FieldType
+
+	logLimits  attributeLimits
+	spanLimits attributeLimits
+
+	bodyMode       BodyMode
+	eventNameField string
+
+	spanEventFormat SpanEventFormatter
 
 	writers []io.Writer
 
+	attributeProcessors     []AttributeProcessor
+	processWriterAttributes bool
+
 	loggerOpts []otelLog.LoggerOption
 }
 
@@ -81,6 +128,34 @@ func WithLoggerProvider(provider otelLog.LoggerProvider) Option {
 	})
 }
 
+// WithTracerProvider returns an [Option] that configures the [trace.TracerProvider]
+// used by a [Hook] to create spans of its own, such as with [WithOrphanSpans].
+//
+// By default if this Option is not provided, the Hook will use the global
+// TracerProvider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return optFunc(func(c config) config {
+		c.tracerProvider = provider
+		return c
+	})
+}
+
+// WithMeterProvider returns an [Option] that configures the [Hook] to count the
+// log records it handles with the [metric.MeterProvider]. A "log.records" counter
+// is incremented for every record with its severity_text and logger.name, as well
+// as the value of each of the provided zerolog fields if present. An "exceptions"
+// counter is incremented for every record carrying an error, by exception.type.
+//
+// Counters are incremented with the event's context, so that exemplars link to
+// the current span.
+func WithMeterProvider(provider metric.MeterProvider, fields ...string) Option {
+	return optFunc(func(c config) config {
+		c.meterProvider = provider
+		c.metricFields = fields
+		return c
+	})
+}
+
 // WithWriter returns an [Option] that configures writers used by a
 // [Hook]. Multiple writers can be specified.
 func WithWriter(w io.Writer) Option {
@@ -119,8 +194,22 @@ func WithAttachSpanEvent(attach bool) Option {
 	})
 }
 
+// WithSpanEventFormatter returns an [Option] that configures the [Hook] to build
+// the name and attributes of span events with formatter, instead of naming them
+// after the log message and copying every attribute. See [LevelSpanEventFormatter]
+// for a formatter that keeps span events low-cardinality.
+//
+// The formatter only takes effect when span events are enabled with [WithAttachSpanEvent].
+func WithSpanEventFormatter(formatter SpanEventFormatter) Option {
+	return optFunc(func(c config) config {
+		c.spanEventFormat = formatter
+		return c
+	})
+}
+
 // WithSetSpanErrorStatus returns an [Option] that configures the [Hook]
 // to set the span as errored when the provided level or higher is called.
+// It is ignored if a policy is configured with [WithSpanErrorPolicy].
 func WithSetSpanErrorStatus(set bool, level zerolog.Level) Option {
 	return optFunc(func(c config) config {
 		c.setSpanError = set
@@ -129,6 +218,288 @@ func WithSetSpanErrorStatus(set bool, level zerolog.Level) Option {
 	})
 }
 
+// WithFieldTypes returns an [Option] that configures the [Hook] to reconstruct
+// fields written with `.Dur()` or `.Time()` into durations and timestamps, which
+// would otherwise be exported as the plain numbers or strings zerolog writes.
+//
+// Keys are either exact field names (e.g. "elapsed") or, when prefixed with "*",
+// a suffix to match against field names (e.g. "*_duration"). Exact names take
+// precedence over suffixes.
+func WithFieldTypes(fieldTypes map[string]FieldType) Option {
+	return optFunc(func(c config) config {
+		if c.fieldTypes == nil {
+			c.fieldTypes = map[string]FieldType{}
+		}
+		for k, v := range fieldTypes {
+			c.fieldTypes[k] = v
+		}
+		return c
+	})
+}
+
+// WithAttributeProcessor returns an [Option] that configures the [Hook] to run
+// every attribute through the chain of processors before it is added to the
+// otel log record and span event, e.g. to rename fields to semantic convention
+// keys or to redact secrets:
+//
+//	WithAttributeProcessor(true,
+//		RenameAttribute("user_id", "enduser.id"),
+//		DropAttributes("password"),
+//		MaskAttributes("***", "authorization"),
+//	)
+//
+// The error, stack and caller fields are exported under the semconv exception.message,
+// exception.stacktrace and code.filepath keys. The processors see them under their
+// zerolog key first and then under the semconv key, so that e.g. DropAttributes("error")
+// removes the error from the record, the span event and the span status alike.
+//
+// If applyToWriters is true, the processors are also applied to the output of
+// the writers configured with [WithWriter], which see the same two keys for these
+// fields. Multiple calls append to the chain.
+func WithAttributeProcessor(applyToWriters bool, processors ...AttributeProcessor) Option {
+	return optFunc(func(c config) config {
+		c.attributeProcessors = append(c.attributeProcessors, processors...)
+		c.processWriterAttributes = c.processWriterAttributes || applyToWriters
+		return c
+	})
+}
+
+// WithAttributeLimits returns an [Option] that configures the [Hook] to bound the
+// attributes of both the otel log record and the span event. It is equivalent to
+// calling [WithLogAttributeLimits] and [WithSpanAttributeLimits] with the same limits.
+func WithAttributeLimits(maxCount, maxValueLen, maxDepth int) Option {
+	return optFunc(func(c config) config {
+		c = WithLogAttributeLimits(maxCount, maxValueLen, maxDepth).apply(c)
+		c = WithSpanAttributeLimits(maxCount, maxValueLen, maxDepth).apply(c)
+		return c
+	})
+}
+
+// WithLogAttributeLimits returns an [Option] that configures the [Hook] to bound
+// the attributes of the otel log record.
+//
+// At most maxCount attributes (and elements of slices and maps) are kept, and the
+// number dropped is recorded in an "otelzlog.dropped_attributes" attribute. String
+// and byte values longer than maxValueLen bytes are cut and marked as truncated, the
+// marker included in the length, and values nested more than maxDepth levels deep are
+// replaced by the truncation marker. A limit of zero disables that limit.
+func WithLogAttributeLimits(maxCount, maxValueLen, maxDepth int) Option {
+	return optFunc(func(c config) config {
+		c.logLimits = attributeLimits{maxCount: maxCount, maxValueLen: maxValueLen, maxDepth: maxDepth}
+		return c
+	})
+}
+
+// WithSpanAttributeLimits returns an [Option] that configures the [Hook] to bound
+// the attributes of the span event in the same way as [WithLogAttributeLimits].
+func WithSpanAttributeLimits(maxCount, maxValueLen, maxDepth int) Option {
+	return optFunc(func(c config) config {
+		c.spanLimits = attributeLimits{maxCount: maxCount, maxValueLen: maxValueLen, maxDepth: maxDepth}
+		return c
+	})
+}
+
+// BodyMode selects what the [Hook] puts in the body of the otel log record.
+type BodyMode int
+
+const (
+	// BodyMessage sets the body to the log message, with all fields as attributes.
+	BodyMessage BodyMode = iota
+	// BodyMap sets the body to a map of all fields, including the message under
+	// zerolog.MessageFieldName. The fields are also kept as attributes, so every
+	// field is exported twice and each record is roughly double the size.
+	BodyMap
+	// BodyRawJSON sets the body to the original zerolog JSON line. The fields are
+	// also kept as attributes. If attribute processors or log attribute limits are
+	// configured, the line is rebuilt from the processed and limited attributes
+	// instead, so that dropped and redacted fields don't reach the body.
+	BodyRawJSON
+)
+
+// WithBodyMode returns an [Option] that configures what the [Hook] puts in the
+// body of the otel log record. Defaults to [BodyMessage].
+func WithBodyMode(mode BodyMode) Option {
+	return optFunc(func(c config) config {
+		c.bodyMode = mode
+		return c
+	})
+}
+
+// WithEventNameField returns an [Option] that configures the [Hook] to lift the
+// string field with the given name out of the attributes and use it as the otel
+// log record's event name, as well as the name of the span event in place of the
+// log message, e.g.
+//
+//	log.Ctx(ctx).Info().Ctx(ctx).Str("event", "user.login").Msg("user logged in")
+func WithEventNameField(field string) Option {
+	return optFunc(func(c config) config {
+		c.eventNameField = field
+		return c
+	})
+}
+
+// WithOrphanSpans returns an [Option] that configures the [Hook] to create a
+// short-lived span for events at the provided level or higher that are logged
+// without a recording span in their context. The span is named after the log
+// message, carries the log as a span event, is marked as errored and ends as
+// soon as the event has been handled, so that orphaned errors still appear in
+// the tracing backend. The log record is correlated with the new span.
+//
+// Spans are created from the [trace.TracerProvider] set by [WithTracerProvider].
+func WithOrphanSpans(create bool, level zerolog.Level) Option {
+	return optFunc(func(c config) config {
+		c.orphanSpans = create
+		c.orphanSpanLevel = level
+		return c
+	})
+}
+
+// WithSpanErrorPolicy returns an [Option] that configures the [Hook] to decide
+// with policy which events mark their span as errored, in place of the level
+// threshold of [WithSetSpanErrorStatus]. The same policy decides whether an
+// event's error is recorded as an exception, e.g.
+//
+//	WithSpanErrorPolicy(IgnoreErrors(
+//		AnySpanErrorPolicy(ErrorOnErr(), ErrorOnLevel(zerolog.ErrorLevel)),
+//		context.Canceled,
+//	))
+func WithSpanErrorPolicy(policy SpanErrorPolicy) Option {
+	return optFunc(func(c config) config {
+		c.spanErrorPolicy = policy
+		return c
+	})
+}
+
+// WithSpanStatusFormatter returns an [Option] that configures how the [Hook]
+// describes the error status it sets with [WithSetSpanErrorStatus]. By default the
+// description is the error message, or the log message if there is no error.
+func WithSpanStatusFormatter(formatter SpanStatusFormatter) Option {
+	return optFunc(func(c config) config {
+		c.spanStatusFormat = formatter
+		return c
+	})
+}
+
+// WithSetSpanOkStatus returns an [Option] that configures the [Hook] to set the
+// span status to ok when an event is logged at exactly the provided level, or when
+// the event has a bool field with the provided name set to true. Pass
+// zerolog.Disabled as the level to only match on the field, or an empty field to
+// only match on the level.
+//
+// Neither this nor [WithSetSpanErrorStatus] changes a status that has already
+// been set on the span.
+func WithSetSpanOkStatus(set bool, level zerolog.Level, field string) Option {
+	return optFunc(func(c config) config {
+		c.setSpanOk = set
+		c.setSpanOkLevel = level
+		c.setSpanOkField = field
+		return c
+	})
+}
+
+// WithTraceSampling returns an [Option] that configures the [Hook] to sample the
+// events it exports in line with trace sampling. Events at warn level or above
+// and events belonging to a sampled span are always exported, so trace views stay
+// complete, while other events are exported at rate, between 0 and 1.
+//
+// Sampled out events are still written to the zerolog writers, and still counted by
+// the metrics of [WithMeterProvider].
+func WithTraceSampling(rate float64) Option {
+	return optFunc(func(c config) config {
+		c.sampler = &traceSampler{rate: rate}
+		return c
+	})
+}
+
+// WithDeduplication returns an [Option] that configures the [Hook] to suppress
+// repeated records before they are exported. The first record with a given
+// message, level and source location (if enabled with [WithSource]) is exported,
+// and its duplicates are suppressed until window has passed. A single summary
+// record, a copy of the last duplicate with a "log.suppressed_count" attribute,
+// is then exported.
+//
+// Suppressed records are still written to the zerolog writers and attached to spans.
+func WithDeduplication(window time.Duration) Option {
+	return optFunc(func(c config) config {
+		c.dedupWindow = window
+		return c
+	})
+}
+
+// WithAsync returns an [Option] that configures the [Hook] to export records from
+// a worker goroutine instead of the logging goroutine. Records are copied onto a
+// queue of queueSize, and policy decides what happens when it is full.
+//
+// Use [Flush] to wait for the queue to drain, [Shutdown] to drain it and stop the
+// worker before shutting down, and [DroppedRecords] to monitor how many records
+// were dropped.
+func WithAsync(queueSize int, policy DropPolicy) Option {
+	return optFunc(func(c config) config {
+		c.async = true
+		c.asyncQueueSize = queueSize
+		c.asyncDropPolicy = policy
+		return c
+	})
+}
+
+// WithFlushOnFatal returns an [Option] that configures the [Hook] to synchronously
+// flush the otel pipeline on fatal and panic events, before zerolog exits or panics.
+// The span in the event's context is set to an error status and ended, the queue of
+// an asynchronous [Hook] is drained, and the LoggerProvider and TracerProvider are
+// force flushed if they support it, all within timeout (5s if zero).
+//
+// Providers that aren't set with [WithLoggerProvider] and [WithTracerProvider] are
+// looked up from the otel globals when flushing, so that SDK providers registered
+// after [New] is called are still flushed.
+func WithFlushOnFatal(flush bool, timeout time.Duration) Option {
+	return optFunc(func(c config) config {
+		c.flushOnFatal = flush
+		c.flushTimeout = timeout
+		return c
+	})
+}
+
+// WithErrorHandler returns an [Option] that sets the handler for the errors the [Hook]
+// runs into, such as undecodable events, field conflicts and failed flushes. It
+// defaults to otel.Handle.
+//
+// Errors raised from within the handler are discarded, so a handler that logs through
+// the hooked logger can't recurse, and are counted in Stats.DiscardedErrors. Errors
+// raised on other goroutines while the handler runs are still handled, so the handler
+// must be safe for concurrent use. See [HookStats].
+func WithErrorHandler(handler func(error)) Option {
+	return optFunc(func(c config) config {
+		c.errorHandler = handler
+		return c
+	})
+}
+
+// WithFlattenMaps returns an [Option] that configures the [Hook] to flatten fields
+// holding objects, such as those written with `.Dict()`, into one attribute per
+// nested field with dotted keys, e.g. `.Dict("http", zerolog.Dict().Str("method", m))`
+// into an "http.method" attribute instead of an "http" map attribute.
+func WithFlattenMaps(flatten bool) Option {
+	return optFunc(func(c config) config {
+		c.flattenMaps = flatten
+		return c
+	})
+}
+
+// WithRawJSONBytes returns an [Option] that configures the [Hook] to keep fields
+// written with [Event.RawJSON] that aren't JSON objects as verbatim bytes instead of
+// a verbatim string.
+//
+// Only [Event.RawJSON] keeps raw JSON verbatim. A field written with zerolog's own
+// `.RawJSON()` can't be told apart from any other field in the encoded event, so it is
+// decoded like one, e.g. `.RawJSON("r", []byte("[1,2]"))` becomes a slice of Float64
+// values, and this option has no effect on it.
+func WithRawJSONBytes(asBytes bool) Option {
+	return optFunc(func(c config) config {
+		c.rawJSONBytes = asBytes
+		return c
+	})
+}
+
 // WithStackHandling returns an [Option] that sets zerolog.ErrorStackMarshaler
 // in order to extract the stack when .Stack() is called on a .Error() event.
 //
@@ -154,6 +525,12 @@ func newCfg(options []Option) config {
 
 	if c.provider == nil {
 		c.provider = global.GetLoggerProvider()
+		c.globalProvider = true
+	}
+
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+		c.globalTracerProvider = true
 	}
 
 	return c
@@ -169,24 +546,115 @@ func New(ctx context.Context, name string, options ...Option) context.Context {
 	case len(cfg.writers) == 0:
 		logger = log.Logger
 
+	case cfg.processWriterAttributes:
+		logger = logger.Output(processingWriter{
+			w:          io.MultiWriter(cfg.writers...),
+			processors: cfg.attributeProcessors,
+		})
+
 	default:
 		logger = logger.Output(io.MultiWriter(cfg.writers...))
 	}
 
 	hook := Hook{
 		otelLogger:        cfg.provider.Logger(name, cfg.loggerOpts...),
+		loggerProvider:    cfg.provider,
 		source:            cfg.source,
 		attachSpanError:   cfg.attachSpanError,
 		attachSpanEvent:   cfg.attachSpanEvent,
 		setSpanError:      cfg.setSpanError,
 		setSpanErrorLevel: cfg.setSpanErrorLevel,
+		fieldTypes:        cfg.fieldTypes,
+		processors:        cfg.attributeProcessors,
+		logLimits:         cfg.logLimits,
+		spanLimits:        cfg.spanLimits,
+		bodyMode:          cfg.bodyMode,
+		eventNameField:    cfg.eventNameField,
+		spanEventFormat:   cfg.spanEventFormat,
+		spanStatusFormat:  cfg.spanStatusFormat,
+		setSpanOk:         cfg.setSpanOk,
+		setSpanOkLevel:    cfg.setSpanOkLevel,
+		setSpanOkField:    cfg.setSpanOkField,
+		spanErrorPolicy:   cfg.spanErrorPolicy,
+		tracerProvider:    cfg.tracerProvider,
+		orphanSpans:       cfg.orphanSpans,
+		orphanSpanLevel:   cfg.orphanSpanLevel,
+		sampler:           cfg.sampler,
+		flushOnFatal:      cfg.flushOnFatal,
+		flushTimeout:      cfg.flushTimeout,
+		errorHandler:      cfg.errorHandler,
+		flattenMaps:       cfg.flattenMaps,
+		rawJSONBytes:      cfg.rawJSONBytes,
 	}
 
+	if cfg.async {
+		hook.async = newAsyncEmitter(hook.exportRecord, cfg.asyncQueueSize, cfg.asyncDropPolicy)
+	}
+
+	if cfg.dedupWindow > 0 {
+		hook.dedup = newDeduplicator(cfg.dedupWindow, hook.emit)
+	}
+
+	if cfg.meterProvider != nil {
+		metrics, err := newLogMetrics(cfg.meterProvider, name, cfg.metricFields)
+		if err != nil {
+			hook.handleError(err)
+		}
+		hook.metrics = metrics
+	}
+
+	// providers taken from the globals are looked up again when flushing
+	hook.globalLoggerProvider = cfg.globalProvider
+	hook.globalTracerProvider = cfg.globalTracerProvider
+
 	if cfg.source {
-		logger = logger.With().CallerWithSkipFrameCount(cfg.sourceOffset + 2).Logger()
+		logger = logger.Hook(callerHook{skip: cfg.sourceOffset})
 	}
 
 	ctx = logger.Hook(&hook).WithContext(ctx)
+	ctx = context.WithValue(ctx, hookKey{}, &hook)
 
 	return ctx
 }
+
+// hookKey is the context key under which [New] stores its [Hook].
+type hookKey struct{}
+
+// hookFromContext returns the [Hook] created by [New] for ctx, or nil if there is none.
+func hookFromContext(ctx context.Context) *Hook {
+	hook, _ := ctx.Value(hookKey{}).(*Hook)
+	return hook
+}
+
+// Flush waits until every record queued by the asynchronous [Hook] created by
+// [New] for ctx has been emitted, or ctx is done. See [WithAsync].
+func Flush(ctx context.Context) error {
+	hook := hookFromContext(ctx)
+	if hook == nil {
+		return nil
+	}
+
+	return hook.Flush(ctx)
+}
+
+// Shutdown drains the queue of the asynchronous [Hook] created by [New] for ctx and
+// stops its worker goroutine, or gives up once ctx is done. See [WithAsync].
+func Shutdown(ctx context.Context) error {
+	hook := hookFromContext(ctx)
+	if hook == nil {
+		return nil
+	}
+
+	return hook.Shutdown(ctx)
+}
+
+// DroppedRecords returns the number of records dropped by the asynchronous [Hook]
+// created by [New] for ctx. See [WithAsync].
+func DroppedRecords(ctx context.Context) uint64 {
+	hook := hookFromContext(ctx)
+	if hook == nil {
+		return 0
+	}
+
+	return hook.DroppedRecords()
+}
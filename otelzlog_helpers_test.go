@@ -8,6 +8,7 @@ import (
 	"github.com/adreasnow/otelstack"
 	"github.com/adreasnow/otelstack/jaeger"
 	"github.com/adreasnow/otelstack/seq"
+	"github.com/adreasnow/otelzlog/otelzlogtest"
 
 	"github.com/docker/go-connections/nat"
 	"github.com/rs/zerolog/log"
@@ -197,3 +198,16 @@ func checkEvents(t *testing.T, stack *otelstack.Stack, spanID string, traceID st
 		})
 	}
 }
+
+// newRecorder returns an [otelzlogtest.Recorder] whose tracer provider is shut down
+// once the test ends.
+func newRecorder(t *testing.T) *otelzlogtest.Recorder {
+	t.Helper()
+
+	rec := otelzlogtest.NewRecorder()
+	t.Cleanup(func() {
+		assert.NoError(t, rec.TracerProvider().Shutdown(context.Background()))
+	})
+
+	return rec
+}
@@ -3,12 +3,15 @@ package otelzlog
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/log/noop"
+	metricNoop "go.opentelemetry.io/otel/metric/noop"
+	traceNoop "go.opentelemetry.io/otel/trace/noop"
 )
 
 func TestNew(t *testing.T) {
@@ -155,3 +158,190 @@ func TestWithSetSpanErrorStatus(t *testing.T) {
 	assert.True(t, c.setSpanError)
 	assert.Equal(t, zerolog.ErrorLevel, c.setSpanErrorLevel)
 }
+
+func TestWithFieldTypes(t *testing.T) {
+	c := config{}
+
+	c = WithFieldTypes(map[string]FieldType{"elapsed": FieldTypeDuration}).apply(c)
+	c = WithFieldTypes(map[string]FieldType{"*_at": FieldTypeTime}).apply(c)
+
+	assert.Equal(t, map[string]FieldType{
+		"elapsed": FieldTypeDuration,
+		"*_at":    FieldTypeTime,
+	}, c.fieldTypes)
+}
+
+func TestWithAttributeProcessor(t *testing.T) {
+	c := config{}
+
+	c = WithAttributeProcessor(false, DropAttributes("a")).apply(c)
+	c = WithAttributeProcessor(true, DropAttributes("b"), DropAttributes("c")).apply(c)
+	c = WithAttributeProcessor(false).apply(c)
+
+	assert.Len(t, c.attributeProcessors, 3)
+	assert.True(t, c.processWriterAttributes)
+}
+
+func TestNewWithAttributeProcessorWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ctx := New(t.Context(),
+		"test",
+		WithLoggerProvider(noop.NewLoggerProvider()),
+		WithWriter(zerolog.ConsoleWriter{Out: buf, NoColor: true}),
+		WithAttributeProcessor(true, MaskAttributes("***", "password")),
+	)
+
+	log.Ctx(ctx).Info().Str("password", "hunter2").Msg("test message")
+
+	assert.Contains(t, buf.String(), "INF test message password=***\n")
+}
+
+func TestWithAttributeLimits(t *testing.T) {
+	c := config{}
+
+	c = WithAttributeLimits(10, 100, 2).apply(c)
+	assert.Equal(t, attributeLimits{maxCount: 10, maxValueLen: 100, maxDepth: 2}, c.logLimits)
+	assert.Equal(t, attributeLimits{maxCount: 10, maxValueLen: 100, maxDepth: 2}, c.spanLimits)
+
+	c = WithLogAttributeLimits(20, 0, 0).apply(c)
+	c = WithSpanAttributeLimits(5, 50, 1).apply(c)
+	assert.Equal(t, attributeLimits{maxCount: 20}, c.logLimits)
+	assert.Equal(t, attributeLimits{maxCount: 5, maxValueLen: 50, maxDepth: 1}, c.spanLimits)
+}
+
+func TestWithBodyMode(t *testing.T) {
+	c := config{}
+
+	c = WithBodyMode(BodyRawJSON).apply(c)
+
+	assert.Equal(t, BodyRawJSON, c.bodyMode)
+}
+
+func TestWithEventNameField(t *testing.T) {
+	c := config{}
+
+	c = WithEventNameField("event").apply(c)
+
+	assert.Equal(t, "event", c.eventNameField)
+}
+
+func TestWithSpanEventFormatter(t *testing.T) {
+	c := config{}
+
+	c = WithSpanEventFormatter(LevelSpanEventFormatter()).apply(c)
+
+	assert.NotNil(t, c.spanEventFormat)
+}
+
+func TestWithSpanStatusFormatter(t *testing.T) {
+	c := config{}
+
+	c = WithSpanStatusFormatter(defaultSpanStatusFormatter).apply(c)
+
+	assert.NotNil(t, c.spanStatusFormat)
+}
+
+func TestWithSetSpanOkStatus(t *testing.T) {
+	c := config{}
+
+	c = WithSetSpanOkStatus(true, zerolog.Disabled, "success").apply(c)
+
+	assert.True(t, c.setSpanOk)
+	assert.Equal(t, zerolog.Disabled, c.setSpanOkLevel)
+	assert.Equal(t, "success", c.setSpanOkField)
+}
+
+func TestWithSpanErrorPolicy(t *testing.T) {
+	c := config{}
+
+	c = WithSpanErrorPolicy(ErrorOnErr()).apply(c)
+
+	assert.NotNil(t, c.spanErrorPolicy)
+}
+
+func TestWithTracerProvider(t *testing.T) {
+	c := config{}
+	provider := traceNoop.NewTracerProvider()
+
+	c = WithTracerProvider(provider).apply(c)
+
+	assert.Equal(t, provider, c.tracerProvider)
+}
+
+func TestWithOrphanSpans(t *testing.T) {
+	c := config{}
+
+	c = WithOrphanSpans(true, zerolog.ErrorLevel).apply(c)
+
+	assert.True(t, c.orphanSpans)
+	assert.Equal(t, zerolog.ErrorLevel, c.orphanSpanLevel)
+}
+
+func TestWithMeterProvider(t *testing.T) {
+	c := config{}
+	provider := metricNoop.NewMeterProvider()
+
+	c = WithMeterProvider(provider, "service").apply(c)
+
+	assert.Equal(t, provider, c.meterProvider)
+	assert.Equal(t, []string{"service"}, c.metricFields)
+}
+
+func TestWithTraceSampling(t *testing.T) {
+	c := config{}
+
+	c = WithTraceSampling(0.1).apply(c)
+
+	assert.Equal(t, &traceSampler{rate: 0.1}, c.sampler)
+}
+
+func TestWithDeduplication(t *testing.T) {
+	c := config{}
+
+	c = WithDeduplication(time.Second).apply(c)
+
+	assert.Equal(t, time.Second, c.dedupWindow)
+}
+
+func TestWithAsync(t *testing.T) {
+	c := config{}
+
+	c = WithAsync(100, DropOldest).apply(c)
+
+	assert.True(t, c.async)
+	assert.Equal(t, 100, c.asyncQueueSize)
+	assert.Equal(t, DropOldest, c.asyncDropPolicy)
+}
+
+func TestWithFlushOnFatal(t *testing.T) {
+	c := config{}
+
+	c = WithFlushOnFatal(true, time.Second).apply(c)
+
+	assert.True(t, c.flushOnFatal)
+	assert.Equal(t, time.Second, c.flushTimeout)
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	c := config{}
+
+	c = WithErrorHandler(func(error) {}).apply(c)
+
+	assert.NotNil(t, c.errorHandler)
+}
+
+func TestWithFlattenMaps(t *testing.T) {
+	c := config{}
+
+	c = WithFlattenMaps(true).apply(c)
+
+	assert.True(t, c.flattenMaps)
+}
+
+func TestWithRawJSONBytes(t *testing.T) {
+	c := config{}
+
+	c = WithRawJSONBytes(true).apply(c)
+
+	assert.True(t, c.rawJSONBytes)
+}
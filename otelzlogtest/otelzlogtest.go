@@ -0,0 +1,157 @@
+// Package otelzlogtest provides an in-memory otel logger and tracer provider to
+// test the records and span events produced through otelzlog, without an otel
+// collector, e.g.
+//
+//	rec := otelzlogtest.NewRecorder()
+//	ctx := otelzlog.New(t.Context(), "test",
+//		otelzlog.WithLoggerProvider(rec),
+//		otelzlog.WithTracerProvider(rec.TracerProvider()),
+//	)
+//
+//	log.Ctx(ctx).Info().Ctx(ctx).Str("key", "value").Msg("test log")
+//
+//	otelzlogtest.AssertRecord(t, rec.Records()[0], log.SeverityInfo, "test log", log.String("key", "value"))
+package otelzlogtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	otelLog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/embedded"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Record is an otel log record emitted to a [Recorder].
+type Record struct {
+	otelLog.Record
+
+	// LoggerName is the name of the logger that emitted the record.
+	LoggerName string
+	// SpanContext is the span context the record was emitted with, which the SDK
+	// uses to correlate the record with the trace.
+	SpanContext trace.SpanContext
+}
+
+// Attributes returns the attributes of the record keyed by attribute key.
+func (r Record) Attributes() map[string]otelLog.Value {
+	attrs := map[string]otelLog.Value{}
+	r.WalkAttributes(func(kv otelLog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	return attrs
+}
+
+// Recorder is an otel LoggerProvider that keeps every record emitted through its
+// loggers, paired with an SDK TracerProvider that keeps every ended span. It is
+// safe for concurrent use.
+type Recorder struct {
+	embedded.LoggerProvider
+
+	mu      sync.Mutex
+	records []Record
+
+	spans          *tracetest.SpanRecorder
+	tracerProvider *sdktrace.TracerProvider
+}
+
+// NewRecorder creates an empty [Recorder].
+func NewRecorder() *Recorder {
+	spans := tracetest.NewSpanRecorder()
+
+	return &Recorder{
+		spans:          spans,
+		tracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+	}
+}
+
+// Logger returns a logger that records into the [Recorder].
+func (r *Recorder) Logger(name string, _ ...otelLog.LoggerOption) otelLog.Logger {
+	return &logger{name: name, recorder: r}
+}
+
+// TracerProvider returns the tracer provider whose spans are recorded.
+func (r *Recorder) TracerProvider() *sdktrace.TracerProvider {
+	return r.tracerProvider
+}
+
+// Records returns the records emitted so far, in order.
+func (r *Recorder) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Record{}, r.records...)
+}
+
+// Spans returns the spans ended so far, in order.
+func (r *Recorder) Spans() []sdktrace.ReadOnlySpan {
+	return r.spans.Ended()
+}
+
+// SpanEvents returns the events of every ended span named spanName, in order.
+func (r *Recorder) SpanEvents(spanName string) []sdktrace.Event {
+	var events []sdktrace.Event
+	for _, span := range r.spans.Ended() {
+		if span.Name() == spanName {
+			events = append(events, span.Events()...)
+		}
+	}
+	return events
+}
+
+type logger struct {
+	embedded.Logger
+
+	name     string
+	recorder *Recorder
+}
+
+func (l *logger) Emit(ctx context.Context, record otelLog.Record) {
+	l.recorder.mu.Lock()
+	defer l.recorder.mu.Unlock()
+
+	l.recorder.records = append(l.recorder.records, Record{
+		Record:      record,
+		LoggerName:  l.name,
+		SpanContext: trace.SpanContextFromContext(ctx),
+	})
+}
+
+func (l *logger) Enabled(context.Context, otelLog.EnabledParameters) bool {
+	return true
+}
+
+// AssertRecord checks that rec has the severity, the string body and at least the
+// attributes attrs, reporting every mismatch to t. It returns whether rec matched.
+func AssertRecord(t testing.TB, rec Record, severity otelLog.Severity, body string, attrs ...otelLog.KeyValue) bool {
+	t.Helper()
+
+	ok := true
+	if rec.Severity() != severity {
+		t.Errorf("otelzlogtest: expected severity %s, got %s", severity, rec.Severity())
+		ok = false
+	}
+
+	if rec.Body().Kind() != otelLog.KindString || rec.Body().AsString() != body {
+		t.Errorf("otelzlogtest: expected body %q, got %s", body, rec.Body())
+		ok = false
+	}
+
+	recAttrs := rec.Attributes()
+	for _, attr := range attrs {
+		v, found := recAttrs[attr.Key]
+		switch {
+		case !found:
+			t.Errorf("otelzlogtest: expected attribute %q, but it is missing", attr.Key)
+			ok = false
+		case !v.Equal(attr.Value):
+			t.Errorf("otelzlogtest: expected attribute %q to be %s, got %s", attr.Key, attr.Value, v)
+			ok = false
+		}
+	}
+
+	return ok
+}
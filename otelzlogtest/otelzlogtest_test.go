@@ -0,0 +1,76 @@
+package otelzlogtest_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/adreasnow/otelzlog"
+	"github.com/adreasnow/otelzlog/otelzlogtest"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+// failingT records the failures reported by AssertRecord instead of failing the test.
+type failingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *failingT) Helper() {}
+
+func (t *failingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	rec := otelzlogtest.NewRecorder()
+	ctx := otelzlog.New(t.Context(), "test",
+		otelzlog.WithLoggerProvider(rec),
+		otelzlog.WithTracerProvider(rec.TracerProvider()),
+		otelzlog.WithAttachSpanEvent(true),
+		otelzlog.WithWriter(io.Discard),
+	)
+
+	ctx, span := rec.TracerProvider().Tracer("test").Start(ctx, "test.segment")
+	log.Ctx(ctx).Info().Ctx(ctx).Str("key", "value").Msg("test log")
+	span.End()
+
+	records := rec.Records()
+	require.Len(t, records, 1)
+	assert.True(t, otelzlogtest.AssertRecord(t, records[0], otelLog.SeverityInfo, "test log",
+		otelLog.String("key", "value"),
+		otelLog.String("level", "info"),
+	))
+	assert.Equal(t, "test", records[0].LoggerName)
+	assert.Equal(t, span.SpanContext(), records[0].SpanContext)
+
+	require.Len(t, rec.Spans(), 1)
+	events := rec.SpanEvents("test.segment")
+	require.Len(t, events, 1)
+	assert.Equal(t, "test log", events[0].Name)
+	assert.Empty(t, rec.SpanEvents("other.segment"))
+}
+
+func TestAssertRecord(t *testing.T) {
+	rec := otelzlogtest.NewRecorder()
+	record := otelLog.Record{}
+	record.SetSeverity(otelLog.SeverityWarn)
+	record.SetBody(otelLog.StringValue("test log"))
+	record.AddAttributes(otelLog.String("key", "value"))
+	rec.Logger("test").Emit(t.Context(), record)
+
+	ft := &failingT{TB: t}
+	assert.False(t, otelzlogtest.AssertRecord(ft, rec.Records()[0], otelLog.SeverityInfo, "other log",
+		otelLog.String("key", "other"),
+		otelLog.String("missing", "value"),
+	))
+	assert.Equal(t, []string{
+		"otelzlogtest: expected severity INFO, got WARN",
+		`otelzlogtest: expected body "other log", got test log`,
+		`otelzlogtest: expected attribute "key" to be other, got value`,
+		`otelzlogtest: expected attribute "missing", but it is missing`,
+	}, ft.errors)
+}
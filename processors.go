@@ -0,0 +1,277 @@
+// Package otelzlog processors hold the attribute processors that rename, drop
+// and redact attributes before they are exported
+package otelzlog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+	otelLog "go.opentelemetry.io/otel/log"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// AttributeProcessor transforms an attribute before it is exported. Returning
+// false drops the attribute. See [WithAttributeProcessor].
+type AttributeProcessor func(otelLog.KeyValue) (otelLog.KeyValue, bool)
+
+// RenameAttribute returns an [AttributeProcessor] that renames the attribute
+// from to the key to, e.g. `RenameAttribute("user_id", "enduser.id")`.
+func RenameAttribute(from, to string) AttributeProcessor {
+	return func(kv otelLog.KeyValue) (otelLog.KeyValue, bool) {
+		if kv.Key == from {
+			kv.Key = to
+		}
+		return kv, true
+	}
+}
+
+// DropAttributes returns an [AttributeProcessor] that drops the attributes
+// with the given keys. Keys are matched case-insensitively.
+func DropAttributes(keys ...string) AttributeProcessor {
+	return func(kv otelLog.KeyValue) (otelLog.KeyValue, bool) {
+		return kv, !matchKey(kv.Key, keys)
+	}
+}
+
+// HashAttributes returns an [AttributeProcessor] that replaces the values of
+// the attributes with the given keys with the hex encoded sha256 hash of the
+// value, so that they can still be correlated without being exposed. Keys are
+// matched case-insensitively.
+func HashAttributes(keys ...string) AttributeProcessor {
+	return func(kv otelLog.KeyValue) (otelLog.KeyValue, bool) {
+		if !matchKey(kv.Key, keys) {
+			return kv, true
+		}
+
+		sum := sha256.Sum256([]byte(kv.Value.String()))
+		kv.Value = otelLog.StringValue(hex.EncodeToString(sum[:]))
+		return kv, true
+	}
+}
+
+// MaskAttributes returns an [AttributeProcessor] that replaces the values of
+// the attributes with the given keys with mask. Keys are matched case-insensitively.
+func MaskAttributes(mask string, keys ...string) AttributeProcessor {
+	return func(kv otelLog.KeyValue) (otelLog.KeyValue, bool) {
+		if matchKey(kv.Key, keys) {
+			kv.Value = otelLog.StringValue(mask)
+		}
+		return kv, true
+	}
+}
+
+// MaskPattern returns an [AttributeProcessor] that replaces every match of
+// pattern in string attribute values with mask, e.g. to redact bearer tokens
+// wherever they appear.
+func MaskPattern(pattern *regexp.Regexp, mask string) AttributeProcessor {
+	return func(kv otelLog.KeyValue) (otelLog.KeyValue, bool) {
+		if kv.Value.Kind() == otelLog.KindString {
+			kv.Value = otelLog.StringValue(pattern.ReplaceAllString(kv.Value.AsString(), mask))
+		}
+		return kv, true
+	}
+}
+
+func matchKey(key string, keys []string) bool {
+	return slices.ContainsFunc(keys, func(k string) bool {
+		return strings.EqualFold(k, key)
+	})
+}
+
+// processAttributes runs each attribute through the chain of processors in order,
+// dropping it as soon as a processor rejects it.
+func processAttributes(attrs []otelLog.KeyValue, processors []AttributeProcessor) []otelLog.KeyValue {
+	if len(processors) == 0 {
+		return attrs
+	}
+
+	processed := make([]otelLog.KeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		if kv, keep := processAttribute(kv, processors); keep {
+			processed = append(processed, kv)
+		}
+	}
+
+	return processed
+}
+
+// processAttribute runs kv through the chain of processors in order, returning false
+// as soon as a processor rejects it.
+func processAttribute(kv otelLog.KeyValue, processors []AttributeProcessor) (otelLog.KeyValue, bool) {
+	for _, process := range processors {
+		var keep bool
+		if kv, keep = process(kv); !keep {
+			return kv, false
+		}
+	}
+
+	return kv, true
+}
+
+// exportedKey returns the semconv key that the hook exports the zerolog error, stack
+// or caller field under, and false for every other field.
+func exportedKey(key string) (string, bool) {
+	switch key {
+	case zerolog.ErrorFieldName:
+		return string(semconv.ExceptionMessageKey), true
+	case zerolog.ErrorStackFieldName:
+		return string(semconv.ExceptionStacktraceKey), true
+	case zerolog.CallerFieldName:
+		return string(semconv.CodeFilepathKey), true
+	default:
+		return "", false
+	}
+}
+
+// processField runs a zerolog field through the processors under its own key and then,
+// for the fields the hook exports under a semconv key, under that key as well. The hook
+// runs the processors over the same two names, so a processor matching either one
+// treats the field the same way in the writers and in the export. The field keeps its
+// own key unless a processor renames it.
+func processField(kv otelLog.KeyValue, processors []AttributeProcessor) (otelLog.KeyValue, bool) {
+	kv, keep := processAttribute(kv, processors)
+	key, ok := exportedKey(kv.Key)
+	if !keep || !ok {
+		return kv, keep
+	}
+
+	exported, keep := processAttribute(otelLog.KeyValue{Key: key, Value: kv.Value}, processors)
+	kv.Value = exported.Value
+	return kv, keep
+}
+
+// processingWriter applies the attribute processors to each zerolog JSON line
+// before passing it on to the wrapped writer.
+type processingWriter struct {
+	w          io.Writer
+	processors []AttributeProcessor
+}
+
+// jsonField is a field of a JSON object with its value still encoded.
+type jsonField struct {
+	key   string
+	value json.RawMessage
+}
+
+// Write decodes the zerolog line, runs every field other than the level, message and
+// timestamp through the processors and re-encodes it. Fields keep their order, and
+// the values the processors leave alone keep their encoding. Lines that cannot be
+// decoded, or that the processors don't change, are written unchanged.
+func (w processingWriter) Write(p []byte) (int, error) {
+	fields, rest, ok := decodeJSONFields(p)
+	if !ok {
+		return w.w.Write(p)
+	}
+
+	changed := false
+	out := make([]jsonField, 0, len(fields))
+	for _, field := range fields {
+		switch field.key {
+		case zerolog.LevelFieldName, zerolog.MessageFieldName, zerolog.TimestampFieldName:
+			out = append(out, field)
+			continue
+		}
+
+		var v any
+		decoder := json.NewDecoder(bytes.NewReader(field.value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&v); err != nil {
+			return w.w.Write(p)
+		}
+
+		kv := otelLog.KeyValue{Key: field.key, Value: convertJSONAttribute(v)}
+		processed, keep := processField(kv, w.processors)
+		switch {
+		case !keep:
+			changed = true
+		case processed.Value.Equal(kv.Value):
+			changed = changed || processed.Key != field.key
+			out = append(out, jsonField{key: processed.Key, value: field.value})
+		default:
+			value, err := marshalJSON(convertLogToAny(processed.Value))
+			if err != nil {
+				return w.w.Write(p)
+			}
+			changed = true
+			out = append(out, jsonField{key: processed.Key, value: value})
+		}
+	}
+
+	if !changed {
+		return w.w.Write(p)
+	}
+
+	line := []byte{'{'}
+	for i, field := range out {
+		if i > 0 {
+			line = append(line, ',')
+		}
+		key, err := marshalJSON(field.key)
+		if err != nil {
+			return w.w.Write(p)
+		}
+		line = append(append(append(line, key...), ':'), field.value...)
+	}
+	line = append(append(line, '}'), rest...)
+
+	if _, err := w.w.Write(line); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// decodeJSONFields decodes the fields of the JSON object at the start of p in order,
+// leaving their values encoded, and returns what follows the object. It returns false
+// if p doesn't hold a single JSON object.
+func decodeJSONFields(p []byte) (fields []jsonField, rest []byte, ok bool) {
+	decoder := json.NewDecoder(bytes.NewReader(p))
+	if tok, err := decoder.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, false
+	}
+
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return nil, nil, false
+		}
+		key, _ := tok.(string)
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, false
+		}
+		fields = append(fields, jsonField{key: key, value: value})
+	}
+
+	if tok, err := decoder.Token(); err != nil || tok != json.Delim('}') {
+		return nil, nil, false
+	}
+
+	rest = p[decoder.InputOffset():]
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return nil, nil, false
+	}
+
+	return fields, rest, true
+}
+
+// marshalJSON encodes v like json.Marshal, but without escaping HTML characters, as
+// zerolog doesn't either.
+func marshalJSON(v any) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
@@ -0,0 +1,268 @@
+package otelzlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"regexp"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+func TestAttributeProcessors(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor AttributeProcessor
+		input     otelLog.KeyValue
+		expected  otelLog.KeyValue
+		keep      bool
+	}{
+		{
+			name:      "rename",
+			processor: RenameAttribute("user_id", "enduser.id"),
+			input:     otelLog.String("user_id", "1234"),
+			expected:  otelLog.String("enduser.id", "1234"),
+			keep:      true,
+		},
+		{
+			name:      "rename other key",
+			processor: RenameAttribute("user_id", "enduser.id"),
+			input:     otelLog.String("status", "ok"),
+			expected:  otelLog.String("status", "ok"),
+			keep:      true,
+		},
+		{
+			name:      "drop",
+			processor: DropAttributes("password"),
+			input:     otelLog.String("Password", "hunter2"),
+			keep:      false,
+		},
+		{
+			name:      "drop other key",
+			processor: DropAttributes("password"),
+			input:     otelLog.String("user", "bob"),
+			expected:  otelLog.String("user", "bob"),
+			keep:      true,
+		},
+		{
+			name:      "hash",
+			processor: HashAttributes("email"),
+			input:     otelLog.String("email", "bob@example.com"),
+			expected:  otelLog.String("email", "5ff860bf1190596c7188ab851db691f0f3169c453936e9e1eba2f9a47f7a0018"),
+			keep:      true,
+		},
+		{
+			name:      "mask",
+			processor: MaskAttributes("***", "authorization"),
+			input:     otelLog.String("Authorization", "Bearer abc"),
+			expected:  otelLog.String("Authorization", "***"),
+			keep:      true,
+		},
+		{
+			name:      "mask pattern",
+			processor: MaskPattern(regexp.MustCompile(`Bearer \S+`), "Bearer ***"),
+			input:     otelLog.String("header", "Authorization: Bearer abc.def"),
+			expected:  otelLog.String("header", "Authorization: Bearer ***"),
+			keep:      true,
+		},
+		{
+			name:      "mask pattern non string",
+			processor: MaskPattern(regexp.MustCompile(`\d+`), "***"),
+			input:     otelLog.Int("count", 10),
+			expected:  otelLog.Int("count", 10),
+			keep:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, keep := tt.processor(tt.input)
+			require.Equal(t, tt.keep, keep)
+			if tt.keep {
+				assert.Equal(t, tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestProcessAttributes(t *testing.T) {
+	attrs := []otelLog.KeyValue{
+		otelLog.String("user_id", "1234"),
+		otelLog.String("password", "hunter2"),
+		otelLog.Int("status", 200),
+	}
+
+	out := processAttributes(attrs, []AttributeProcessor{
+		RenameAttribute("user_id", "enduser.id"),
+		RenameAttribute("status", "http.response.status_code"),
+		DropAttributes("password"),
+	})
+
+	assert.Equal(t, []otelLog.KeyValue{
+		otelLog.String("enduser.id", "1234"),
+		otelLog.Int("http.response.status_code", 200),
+	}, out)
+
+	assert.Equal(t, attrs, processAttributes(attrs, nil))
+}
+
+func TestProcessingWriter(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		logger := zerolog.New(processingWriter{
+			w: buf,
+			processors: []AttributeProcessor{
+				RenameAttribute("user_id", "enduser.id"),
+				DropAttributes("password"),
+			},
+		})
+
+		logger.Info().Str("user_id", "1234").Str("password", "hunter2").Int64("big", 1<<60).Msg("test message")
+
+		m := map[string]any{}
+		decoder := json.NewDecoder(buf)
+		decoder.UseNumber()
+		require.NoError(t, decoder.Decode(&m))
+
+		assert.Equal(t, map[string]any{
+			"level":      "info",
+			"message":    "test message",
+			"enduser.id": "1234",
+			"big":        json.Number("1152921504606846976"),
+		}, m)
+	})
+
+	t.Run("order and encoding", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		logger := zerolog.New(processingWriter{
+			w:          buf,
+			processors: []AttributeProcessor{RenameAttribute("user_id", "enduser.id"), MaskAttributes("***", "token")},
+		})
+
+		logger.Info().
+			Str("zeta", "<a>&").
+			Uint64("max", math.MaxUint64).
+			Float64("pi", 3.14159265358979).
+			Str("user_id", "1234").
+			Str("token", "secret").
+			Msg("test message")
+
+		assert.Equal(t, `{"level":"info","zeta":"<a>&","max":18446744073709551615,"pi":3.14159265358979,"enduser.id":"1234","token":"***","message":"test message"}`+"\n", buf.String())
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		w := processingWriter{w: buf, processors: []AttributeProcessor{DropAttributes("password")}}
+
+		line := []byte(`{"level":"info","b":1.0,"a":"<a>","big":18446744073709551615,"message":"test message"}` + "\n")
+		n, err := w.Write(line)
+		require.NoError(t, err)
+		assert.Equal(t, len(line), n)
+		assert.Equal(t, string(line), buf.String())
+	})
+
+	t.Run("exported keys", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		logger := zerolog.New(processingWriter{
+			w:          buf,
+			processors: []AttributeProcessor{MaskAttributes("***", "exception.message"), DropAttributes("exception.stacktrace")},
+		})
+
+		logger.Error().Str("stack", "main.go:1").Str("error", "secret").Msg("test message")
+
+		assert.Equal(t, `{"level":"error","error":"***","message":"test message"}`+"\n", buf.String())
+	})
+
+	t.Run("not json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		w := processingWriter{w: buf, processors: []AttributeProcessor{DropAttributes("a")}}
+
+		n, err := w.Write([]byte("not json\n"))
+		require.NoError(t, err)
+		assert.Equal(t, 9, n)
+		assert.Equal(t, "not json\n", buf.String())
+	})
+}
+
+func TestHookAttributeProcessors(t *testing.T) {
+	recorder := newRecorder(t)
+	tracer := recorder.TracerProvider().Tracer("test")
+
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger:      recorder.Logger("test"),
+		attachSpanEvent: true,
+		processors: []AttributeProcessor{
+			RenameAttribute("user_id", "enduser.id"),
+			MaskAttributes("***", "token"),
+		},
+	})
+
+	ctx, span := tracer.Start(t.Context(), "test.segment")
+	logger.Info().Ctx(ctx).Str("user_id", "1234").Str("token", "secret").Msg("test log")
+	span.End()
+
+	records := recorder.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string]otelLog.Value{
+		"enduser.id": otelLog.StringValue("1234"),
+		"token":      otelLog.StringValue("***"),
+		"level":      otelLog.StringValue("info"),
+	}, records[0].Attributes())
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events(), 1)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("enduser.id", "1234"),
+		attribute.String("token", "***"),
+		attribute.String("level", "info"),
+	}, spans[0].Events()[0].Attributes)
+}
+
+func TestHookAttributeProcessorsExceptionKeys(t *testing.T) {
+	rec := newRecorder(t)
+
+	processors := []AttributeProcessor{
+		DropAttributes("error"),
+		MaskAttributes("***", "stack"),
+	}
+
+	buf := new(bytes.Buffer)
+	logger := zerolog.New(processingWriter{w: buf, processors: processors}).Hook(&Hook{
+		otelLogger:        rec.Logger("test"),
+		attachSpanEvent:   true,
+		setSpanError:      true,
+		setSpanErrorLevel: zerolog.ErrorLevel,
+		processors:        processors,
+	})
+
+	ctx, span := rec.TracerProvider().Tracer("test").Start(t.Context(), "test.segment")
+	logger.Error().Ctx(ctx).Str("stack", "main.go:1").Str("error", "password hunter2 rejected").Msg("test log")
+	span.End()
+
+	assert.JSONEq(t, `{"level":"error","stack":"***","message":"test log"}`, buf.String())
+
+	records := rec.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string]otelLog.Value{
+		"exception.stacktrace": otelLog.StringValue("***"),
+		"level":                otelLog.StringValue("error"),
+	}, records[0].Attributes())
+
+	events := rec.SpanEvents("test.segment")
+	require.Len(t, events, 1)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("exception.stacktrace", "***"),
+		attribute.String("level", "error"),
+	}, events[0].Attributes)
+
+	spans := rec.Spans()
+	require.Len(t, spans, 1)
+	assert.NotContains(t, spans[0].Status().Description, "hunter2")
+}
@@ -0,0 +1,85 @@
+// Package otelzlog recover holds the helpers that recover panics and report them
+// through the hook
+package otelzlog
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recoveredPanicMessage is the message of the event logged for a recovered panic.
+const recoveredPanicMessage = "recovered from panic"
+
+// recoveredKey is the context key that marks the event logged for a recovered panic.
+// Its span already holds the exception, and is still in use by the application once
+// the panic is recovered, so the [Hook] neither adds a span event nor ends it.
+type recoveredKey struct{}
+
+// isRecovered reports whether ctx is that of the event logged for a recovered panic.
+func isRecovered(ctx context.Context) bool {
+	return ctx.Value(recoveredKey{}) != nil
+}
+
+// Recover recovers a panic and reports it, and must be deferred directly, e.g.
+//
+//	defer otelzlog.Recover(ctx, false)
+//
+// The panic is recorded as an exception with "exception.escaped" set on the span in
+// ctx, which is set to an error status, and logged at zerolog.PanicLevel through the
+// context's logger with the stack of the panicking goroutine. The [Hook] created by
+// [New] for ctx is then flushed. The span is left open, even with [WithFlushOnFatal],
+// and doesn't get a span event for the log on top of the exception. If repanic is
+// true, the panic is resumed afterwards.
+func Recover(ctx context.Context, repanic bool) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	reportPanic(ctx, r, string(debug.Stack()))
+
+	if repanic {
+		panic(r)
+	}
+}
+
+// Go runs fn in a new goroutine, recovering and reporting any panic with [Recover]
+// instead of crashing the process.
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	go func() {
+		defer Recover(ctx, false)
+		fn(ctx)
+	}()
+}
+
+func reportPanic(ctx context.Context, r any, stack string) {
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("%v", r)
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err, trace.WithAttributes(
+		semconv.ExceptionEscapedKey.Bool(true),
+		semconv.ExceptionStacktraceKey.String(stack),
+	))
+	span.SetStatus(codes.Error, err.Error())
+
+	// WithLevel logs at the panic level without panicking again
+	eventCtx := context.WithValue(ctx, recoveredKey{}, true)
+	NewEvent(zerolog.Ctx(ctx).WithLevel(zerolog.PanicLevel).Ctx(eventCtx)).
+		Err(err).
+		Str(zerolog.ErrorStackFieldName, stack).
+		Msg(recoveredPanicMessage)
+
+	// the hook already flushed for the panic event if flushing on fatal is enabled
+	if hook := hookFromContext(ctx); hook != nil && !hook.flushOnFatal {
+		hook.forceFlush(ctx)
+	}
+}
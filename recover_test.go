@@ -0,0 +1,130 @@
+package otelzlog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/adreasnow/otelzlog/otelzlogtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+func setupRecover(t *testing.T, options ...Option) (context.Context, *otelzlogtest.Recorder) {
+	t.Helper()
+
+	recorder := newRecorder(t)
+	ctx := New(t.Context(), "test", append([]Option{
+		WithLoggerProvider(recorder),
+		WithTracerProvider(recorder.TracerProvider()),
+		WithWriter(io.Discard),
+	}, options...)...)
+
+	return ctx, recorder
+}
+
+func TestRecover(t *testing.T) {
+	ctx, recorder := setupRecover(t)
+
+	ctx, span := recorder.TracerProvider().Tracer("test").Start(ctx, "test.segment")
+	func() {
+		defer Recover(ctx, false)
+		panic("out of memory")
+	}()
+	span.End()
+
+	require.Len(t, recorder.Records(), 1)
+	record := recorder.Records()[0]
+	assert.Equal(t, recoveredPanicMessage, record.Body().AsString())
+	assert.Equal(t, otelLog.SeverityFatal, record.Severity())
+	attrs := record.Attributes()
+	assert.Equal(t, "out of memory", attrs["exception.message"].AsString())
+	assert.Contains(t, attrs["exception.stacktrace"].AsString(), "recover_test.go")
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "out of memory", spans[0].Status().Description)
+
+	require.NotEmpty(t, spans[0].Events())
+	exception := spans[0].Events()[0]
+	assert.Equal(t, "exception", exception.Name)
+	assert.Contains(t, exception.Attributes, attribute.Bool("exception.escaped", true))
+	assert.Contains(t, exception.Attributes, attribute.String("exception.message", "out of memory"))
+}
+
+func TestRecoverFlushOnFatal(t *testing.T) {
+	ctx, recorder := setupRecover(t, WithAttachSpanEvent(true), WithFlushOnFatal(true, time.Second))
+
+	ctx, span := recorder.TracerProvider().Tracer("test").Start(ctx, "test.segment")
+	func() {
+		defer Recover(ctx, false)
+		panic("out of memory")
+	}()
+
+	// the application carries on with the span after recovering
+	assert.True(t, span.IsRecording())
+	assert.Empty(t, recorder.Spans())
+	span.End()
+
+	require.Len(t, recorder.Records(), 1)
+	require.Len(t, recorder.Spans(), 1)
+
+	events := recorder.SpanEvents("test.segment")
+	require.Len(t, events, 1, "the exception must be recorded once")
+	assert.Equal(t, "exception", events[0].Name)
+	assert.Contains(t, events[0].Attributes, attribute.Bool("exception.escaped", true))
+}
+
+func TestRecoverFlushOnce(t *testing.T) {
+	for _, flushOnFatal := range []bool{false, true} {
+		recorder := newRecorder(t)
+		provider := &flushingProvider{LoggerProvider: recorder}
+		ctx, _ := setupRecover(t, WithLoggerProvider(provider), WithFlushOnFatal(flushOnFatal, time.Second))
+
+		func() {
+			defer Recover(ctx, false)
+			panic("out of memory")
+		}()
+
+		assert.Len(t, recorder.Records(), 1)
+		assert.Equal(t, 1, provider.flushed, "flush on fatal: %t", flushOnFatal)
+	}
+}
+
+func TestRecoverRepanic(t *testing.T) {
+	ctx, recorder := setupRecover(t)
+	err := errors.New("out of memory")
+
+	assert.PanicsWithError(t, err.Error(), func() {
+		defer Recover(ctx, true)
+		panic(err)
+	})
+	assert.Len(t, recorder.Records(), 1)
+}
+
+func TestRecoverNoPanic(t *testing.T) {
+	ctx, recorder := setupRecover(t)
+
+	func() {
+		defer Recover(ctx, false)
+	}()
+
+	assert.Empty(t, recorder.Records())
+}
+
+func TestGo(t *testing.T) {
+	ctx, recorder := setupRecover(t)
+
+	Go(ctx, func(context.Context) {
+		panic("out of memory")
+	})
+
+	require.Eventually(t, func() bool { return len(recorder.Records()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "out of memory", recorder.Records()[0].Attributes()["exception.message"].AsString())
+}
@@ -0,0 +1,31 @@
+// Package otelzlog sampling holds the sampler that decides which zerolog events
+// are exported based on the sampling decision of their trace
+package otelzlog
+
+import (
+	"context"
+	"math/rand/v2"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceSampler decides which events are exported to otel. Events at warn level or
+// above, and events belonging to a sampled span, are always exported. Other events
+// are exported at rate, between 0 and 1.
+type traceSampler struct {
+	rate float64
+}
+
+// shouldExport reports whether the event should be exported. A nil sampler exports everything.
+func (s *traceSampler) shouldExport(ctx context.Context, level zerolog.Level) bool {
+	if s == nil || level >= zerolog.WarnLevel {
+		return true
+	}
+
+	if trace.SpanContextFromContext(ctx).IsSampled() {
+		return true
+	}
+
+	return rand.Float64() < s.rate
+}
@@ -0,0 +1,59 @@
+package otelzlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestTraceSampler(t *testing.T) {
+	sampled := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample())).Tracer("test")
+	unsampled := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample())).Tracer("test")
+
+	sampledCtx, sampledSpan := sampled.Start(t.Context(), "sampled")
+	defer sampledSpan.End()
+	unsampledCtx, unsampledSpan := unsampled.Start(t.Context(), "unsampled")
+	defer unsampledSpan.End()
+
+	tests := []struct {
+		name     string
+		sampler  *traceSampler
+		ctx      context.Context
+		level    zerolog.Level
+		expected bool
+	}{
+		{name: "nil sampler", sampler: nil, ctx: t.Context(), level: zerolog.DebugLevel, expected: true},
+		{name: "warn", sampler: &traceSampler{rate: 0}, ctx: t.Context(), level: zerolog.WarnLevel, expected: true},
+		{name: "error unsampled", sampler: &traceSampler{rate: 0}, ctx: unsampledCtx, level: zerolog.ErrorLevel, expected: true},
+		{name: "info sampled", sampler: &traceSampler{rate: 0}, ctx: sampledCtx, level: zerolog.InfoLevel, expected: true},
+		{name: "info unsampled", sampler: &traceSampler{rate: 0}, ctx: unsampledCtx, level: zerolog.InfoLevel, expected: false},
+		{name: "debug unspanned", sampler: &traceSampler{rate: 0}, ctx: t.Context(), level: zerolog.DebugLevel, expected: false},
+		{name: "debug unspanned full rate", sampler: &traceSampler{rate: 1}, ctx: t.Context(), level: zerolog.DebugLevel, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.sampler.shouldExport(tt.ctx, tt.level))
+		})
+	}
+}
+
+func TestHookTraceSampling(t *testing.T) {
+	recorder := newRecorder(t)
+	buf := new(bytes.Buffer)
+	logger := zerolog.New(buf).Hook(&Hook{
+		otelLogger: recorder.Logger("test"),
+		sampler:    &traceSampler{rate: 0},
+	})
+
+	logger.Info().Msg("dropped")
+	logger.Warn().Msg("kept")
+
+	assert.Len(t, recorder.Records(), 1)
+	assert.Contains(t, buf.String(), "dropped")
+	assert.Contains(t, buf.String(), "kept")
+}
@@ -0,0 +1,207 @@
+// Package otelzlog slog holds the slog handler that writes through the zerolog
+// logger and hook
+package otelzlog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"github.com/rs/zerolog"
+)
+
+// slogField is an attribute added with WithAttrs, resolved and qualified with the
+// groups that were open at the time.
+type slogField struct {
+	key   string
+	value slog.Value
+}
+
+// SlogHandler is a slog.Handler that writes through the zerolog logger of the
+// context, and so through the same [Hook] as the rest of the application's logs:
+// `slog.InfoContext(ctx, ...)` gets the same span events, span status, source
+// handling and attribute conversion as `log.Ctx(ctx).Info().Ctx(ctx)`.
+//
+// Groups are written as dotted prefixes of the field keys, e.g. "http.method". An
+// error under the zerolog error field key, e.g. `slog.Any("error", err)`, is written
+// with `.Err()`, so that it is recorded as the exception and seen by the
+// [SpanErrorPolicy], while errors under other keys are written as their message.
+type SlogHandler struct {
+	ctx       context.Context
+	level     slog.Leveler
+	addSource bool
+
+	fields []slogField
+	prefix string
+}
+
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// NewSlogHandler creates a [SlogHandler]. ctx is the context returned by [New],
+// whose logger is used when a record is logged with a context that has no zerolog
+// logger, such as by `slog.Info`.
+//
+// Of opts, only AddSource and Level are supported. AddSource adds the caller of the
+// record, which the [Hook] turns into the source attributes if [WithSource] is set.
+// Without it, records have no caller.
+// Use [WithAttributeProcessor] in place of ReplaceAttr.
+func NewSlogHandler(ctx context.Context, opts *slog.HandlerOptions) *SlogHandler {
+	h := &SlogHandler{ctx: ctx}
+	if opts != nil {
+		h.level = opts.Level
+		h.addSource = opts.AddSource
+	}
+
+	return h
+}
+
+// logger returns the zerolog logger of ctx, falling back to the logger of the context
+// the handler was created with.
+func (h *SlogHandler) logger(ctx context.Context) *zerolog.Logger {
+	if ctx != nil {
+		if logger := zerolog.Ctx(ctx); logger.GetLevel() != zerolog.Disabled {
+			return logger
+		}
+	}
+
+	return zerolog.Ctx(h.ctx)
+}
+
+// Enabled reports whether the level is enabled for both the handler and the zerolog logger.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.level != nil && level < h.level.Level() {
+		return false
+	}
+
+	zlevel := convertSlogLevel(level)
+	return zlevel >= h.logger(ctx).GetLevel() && zlevel >= zerolog.GlobalLevel()
+}
+
+// Handle writes the record through the zerolog logger as an [Event], so that the
+// attributes keep their native types.
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	zevent := h.logger(ctx).WithLevel(convertSlogLevel(record.Level))
+	if !zevent.Enabled() {
+		return nil
+	}
+	event := NewEvent(zevent.Ctx(ctx))
+
+	if h.addSource && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		event.Caller(frame.File, frame.Line)
+	} else {
+		event.omitCaller()
+	}
+
+	for _, field := range h.fields {
+		addSlogValue(event, field.key, field.value)
+	}
+
+	record.Attrs(func(attr slog.Attr) bool {
+		addSlogAttr(event, h.prefix, attr)
+		return true
+	})
+
+	event.Msg(record.Message)
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every record, under the groups
+// opened so far.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := *h
+	h2.fields = append([]slogField{}, h.fields...)
+	for _, attr := range attrs {
+		h2.fields = appendSlogFields(h2.fields, h.prefix, attr)
+	}
+
+	return &h2
+}
+
+// WithGroup returns a handler that prefixes the keys of the attributes added from
+// now on with name.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+
+	return &h2
+}
+
+// appendSlogFields resolves attr and appends it to fields, flattening groups into
+// dotted keys. Attributes with an empty key, and empty groups, are dropped as per the
+// slog.Handler rules, while groups with an empty key are inlined.
+func appendSlogFields(fields []slogField, prefix string, attr slog.Attr) []slogField {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, nested := range attr.Value.Group() {
+			fields = appendSlogFields(fields, groupPrefix, nested)
+		}
+		return fields
+	}
+
+	if attr.Key == "" {
+		return fields
+	}
+
+	return append(fields, slogField{key: prefix + attr.Key, value: attr.Value})
+}
+
+func addSlogAttr(e *Event, prefix string, attr slog.Attr) {
+	for _, field := range appendSlogFields(nil, prefix, attr) {
+		addSlogValue(e, field.key, field.value)
+	}
+}
+
+// addSlogValue adds the resolved, non-group value v to the event with its native type.
+func addSlogValue(e *Event, key string, v slog.Value) {
+	switch v.Kind() {
+	case slog.KindString:
+		e.Str(key, v.String())
+	case slog.KindInt64:
+		e.Int64(key, v.Int64())
+	case slog.KindUint64:
+		e.Uint64(key, v.Uint64())
+	case slog.KindFloat64:
+		e.Float64(key, v.Float64())
+	case slog.KindBool:
+		e.Bool(key, v.Bool())
+	case slog.KindDuration:
+		e.Dur(key, v.Duration())
+	case slog.KindTime:
+		e.Time(key, v.Time())
+	default:
+		switch val := v.Any().(type) {
+		case error:
+			// like `.Err()`, an error under the error field key is recorded as the exception
+			if key == zerolog.ErrorFieldName {
+				e.Err(val)
+			} else {
+				e.Str(key, val.Error())
+			}
+		case []byte:
+			e.Bytes(key, val)
+		default:
+			e.Interface(key, val)
+		}
+	}
+}
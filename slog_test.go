@@ -0,0 +1,189 @@
+package otelzlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adreasnow/otelzlog/otelzlogtest"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otelLog "go.opentelemetry.io/otel/log"
+)
+
+func setupSlog(t *testing.T, opts *slog.HandlerOptions, options ...Option) (context.Context, *slog.Logger, *otelzlogtest.Recorder) {
+	t.Helper()
+
+	rec := newRecorder(t)
+	ctx := New(t.Context(), "test", append([]Option{
+		WithLoggerProvider(rec),
+		WithTracerProvider(rec.TracerProvider()),
+		WithWriter(io.Discard),
+	}, options...)...)
+
+	return ctx, slog.New(NewSlogHandler(ctx, opts)), rec
+}
+
+func TestSlogHandler(t *testing.T) {
+	ctx, logger, rec := setupSlog(t, nil, WithAttachSpanEvent(true), WithSetSpanErrorStatus(true, zerolog.ErrorLevel))
+
+	ctx, span := rec.TracerProvider().Tracer("test").Start(ctx, "test.segment")
+	logger.With("service", "api").WithGroup("http").
+		ErrorContext(ctx, "request failed",
+			"method", "GET",
+			"status", 500,
+			"elapsed", time.Second,
+			"err", errors.New("connection refused"),
+			slog.Group("user", "id", uint64(10), "admin", false),
+		)
+	span.End()
+
+	records := rec.Records()
+	require.Len(t, records, 1)
+	otelzlogtest.AssertRecord(t, records[0], otelLog.SeverityError, "request failed",
+		otelLog.String("level", "error"),
+		otelLog.String("service", "api"),
+		otelLog.String("http.method", "GET"),
+		otelLog.Int64("http.status", 500),
+		otelLog.Int64("http.elapsed", time.Second.Nanoseconds()),
+		otelLog.String("http.err", "connection refused"),
+		otelLog.Int64("http.user.id", 10),
+		otelLog.Bool("http.user.admin", false),
+	)
+	assert.Equal(t, span.SpanContext(), records[0].SpanContext)
+
+	events := rec.SpanEvents("test.segment")
+	require.Len(t, events, 1)
+	assert.Equal(t, "request failed", events[0].Name)
+	assert.Contains(t, events[0].Attributes, attribute.Int64("http.status", 500))
+
+	require.Len(t, rec.Spans(), 1)
+	assert.Equal(t, "request failed", rec.Spans()[0].Status().Description)
+}
+
+func TestSlogHandlerError(t *testing.T) {
+	ctx, logger, rec := setupSlog(t, nil,
+		WithAttachSpanEvent(true),
+		WithSpanErrorPolicy(IgnoreErrors(ErrorOnErr(), context.Canceled)),
+	)
+
+	ctx, span := rec.TracerProvider().Tracer("test").Start(ctx, "test.segment")
+	logger.InfoContext(ctx, "canceled", "error", fmt.Errorf("slog: %w", context.Canceled))
+	logger.InfoContext(ctx, "request failed", "error", errors.New("connection refused"))
+	span.End()
+
+	records := rec.Records()
+	require.Len(t, records, 2)
+	assert.Empty(t, records[0].EventName())
+	assert.Equal(t, "exception", records[1].EventName())
+	otelzlogtest.AssertRecord(t, records[1], otelLog.SeverityInfo, "request failed",
+		otelLog.String("exception.message", "connection refused"),
+	)
+	assert.NotContains(t, records[1].Attributes(), "error")
+
+	require.Len(t, rec.Spans(), 1)
+	assert.Equal(t, codes.Error, rec.Spans()[0].Status().Code)
+	assert.Equal(t, "connection refused", rec.Spans()[0].Status().Description)
+}
+
+func TestSlogHandlerLevels(t *testing.T) {
+	tests := []struct {
+		level    slog.Level
+		expected otelLog.Severity
+	}{
+		{slog.LevelDebug - 4, otelLog.SeverityTrace},
+		{slog.LevelDebug, otelLog.SeverityDebug},
+		{slog.LevelInfo, otelLog.SeverityInfo},
+		{slog.LevelWarn, otelLog.SeverityWarn},
+		{slog.LevelError, otelLog.SeverityError},
+		{slog.LevelError + 4, otelLog.SeverityError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			ctx, logger, rec := setupSlog(t, &slog.HandlerOptions{Level: slog.LevelDebug - 4})
+
+			logger.Log(ctx, tt.level, "test log")
+
+			records := rec.Records()
+			require.Len(t, records, 1)
+			assert.Equal(t, tt.expected, records[0].Severity())
+		})
+	}
+}
+
+func TestSlogHandlerEnabled(t *testing.T) {
+	ctx, logger, rec := setupSlog(t, &slog.HandlerOptions{Level: slog.LevelWarn})
+
+	logger.InfoContext(ctx, "test log")
+	assert.Empty(t, rec.Records())
+
+	quiet := zerolog.Ctx(ctx).Level(zerolog.ErrorLevel)
+	logger.WarnContext(quiet.WithContext(ctx), "test log")
+	assert.Empty(t, rec.Records())
+
+	// the logger of the handler's context is used if the record's context has none
+	logger.Warn("test log")
+	assert.Len(t, rec.Records(), 1)
+}
+
+func TestSlogHandlerSource(t *testing.T) {
+	t.Run("add source", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		ctx, logger, rec := setupSlog(t, &slog.HandlerOptions{AddSource: true}, WithSource(true, 0), WithWriter(buf))
+
+		_, file, line, _ := runtime.Caller(0)
+		logger.InfoContext(ctx, "test log")
+
+		records := rec.Records()
+		require.Len(t, records, 1)
+		otelzlogtest.AssertRecord(t, records[0], otelLog.SeverityInfo, "test log",
+			otelLog.String("code.filepath", file),
+			otelLog.Int("code.lineno", line+1),
+		)
+
+		assert.Equal(t, 1, strings.Count(buf.String(), `"caller"`))
+		assert.Contains(t, buf.String(), strconv.Quote(file+":"+strconv.Itoa(line+1)))
+	})
+
+	t.Run("no source", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		ctx, logger, rec := setupSlog(t, nil, WithSource(true, 0), WithWriter(buf))
+
+		logger.InfoContext(ctx, "test log")
+
+		records := rec.Records()
+		require.Len(t, records, 1)
+		attrs := records[0].Attributes()
+		assert.NotContains(t, attrs, "code.filepath")
+		assert.NotContains(t, attrs, "code.lineno")
+
+		assert.NotContains(t, buf.String(), `"caller"`)
+	})
+}
+
+func TestAppendSlogFields(t *testing.T) {
+	fields := appendSlogFields(nil, "a.", slog.Group("",
+		slog.String("b", "c"),
+		slog.Group("d", slog.Int("e", 1)),
+		slog.Group("empty"),
+		slog.Attr{},
+	))
+
+	keys := make([]string, 0, len(fields))
+	for _, field := range fields {
+		keys = append(keys, field.key)
+	}
+	assert.Equal(t, "a.b,a.d.e", strings.Join(keys, ","))
+}
@@ -0,0 +1,211 @@
+// Package otelzlog span holds the helpers that shape the span events created
+// from zerolog events
+package otelzlog
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanEventFormatter builds the name and attributes of the span event attached
+// for a zerolog event from its level, message and converted attributes.
+// See [WithSpanEventFormatter].
+type SpanEventFormatter func(level zerolog.Level, msg string, attrs []attribute.KeyValue) (name string, eventAttrs []attribute.KeyValue)
+
+// LevelSpanEventFormatter returns a [SpanEventFormatter] that keeps span events
+// low-cardinality: events are named after the level (e.g. "log.warn"), the message
+// is moved into a "log.message" attribute and the level into "log.severity".
+//
+// If fields are given, only the attributes with those keys are copied onto the
+// event, otherwise all of them are.
+func LevelSpanEventFormatter(fields ...string) SpanEventFormatter {
+	return func(level zerolog.Level, msg string, attrs []attribute.KeyValue) (string, []attribute.KeyValue) {
+		_, severityText := convertLevel(level)
+
+		eventAttrs := make([]attribute.KeyValue, 0, len(attrs)+2)
+		eventAttrs = append(eventAttrs,
+			attribute.String("log.message", msg),
+			attribute.String("log.severity", severityText),
+		)
+
+		for _, attr := range attrs {
+			if len(fields) == 0 || slices.Contains(fields, string(attr.Key)) {
+				eventAttrs = append(eventAttrs, attr)
+			}
+		}
+
+		return "log." + level.String(), eventAttrs
+	}
+}
+
+// SpanStatusFormatter builds the description of the error status set on a span
+// from the level, message and error message of the zerolog event. errMsg is empty
+// if the event has no error. See [WithSpanStatusFormatter].
+type SpanStatusFormatter func(level zerolog.Level, msg string, errMsg string) string
+
+// defaultSpanStatusFormatter describes the status with the error message, falling back
+// to the log message if there is no error.
+func defaultSpanStatusFormatter(_ zerolog.Level, msg string, errMsg string) string {
+	if errMsg != "" {
+		return errMsg
+	}
+	return msg
+}
+
+// maxTrackedSpans is the number of spans [spanStatuses] tracks before it forgets the
+// spans that have ended.
+const maxTrackedSpans = 1024
+
+// spanStatuses tracks the status the hook has set on each span that is still recording,
+// as the trace API has no way to read it back.
+type spanStatuses struct {
+	mu    sync.Mutex
+	spans map[spanKey]trackedSpan
+}
+
+// spanKey identifies a span, [trace.SpanContext] itself isn't comparable.
+type spanKey struct {
+	traceID trace.TraceID
+	spanID  trace.SpanID
+}
+
+func spanKeyOf(span trace.Span) spanKey {
+	sc := span.SpanContext()
+	return spanKey{traceID: sc.TraceID(), spanID: sc.SpanID()}
+}
+
+type trackedSpan struct {
+	span trace.Span
+	code codes.Code
+}
+
+// code returns the status code already set on span, by the hook or, for spans that
+// expose their status like the SDK's, by the application. Spans whose status can't be
+// read are reported as [codes.Unset].
+func (s *spanStatuses) code(span trace.Span) codes.Code {
+	s.mu.Lock()
+	tracked, ok := s.spans[spanKeyOf(span)]
+	s.mu.Unlock()
+	if ok {
+		return tracked.code
+	}
+
+	return readSpanStatusCode(span)
+}
+
+// set sets the status of span and tracks its code until the span ends.
+func (s *spanStatuses) set(span trace.Span, code codes.Code, description string) {
+	span.SetStatus(code, description)
+	if !span.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.spans == nil {
+		s.spans = map[spanKey]trackedSpan{}
+	}
+
+	if len(s.spans) >= maxTrackedSpans {
+		maps.DeleteFunc(s.spans, func(_ spanKey, tracked trackedSpan) bool {
+			return !tracked.span.IsRecording()
+		})
+	}
+
+	s.spans[spanKeyOf(span)] = trackedSpan{span: span, code: code}
+}
+
+// readSpanStatusCode returns the status code of a span that exposes its status through a
+// `Status()` method returning a struct with a [codes.Code] field named Code, as the SDK
+// spans do, without depending on the SDK. It returns [codes.Unset] for any other span.
+func readSpanStatusCode(span trace.Span) codes.Code {
+	method := reflect.ValueOf(span).MethodByName("Status")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return codes.Unset
+	}
+
+	status := method.Call(nil)[0]
+	if status.Kind() != reflect.Struct {
+		return codes.Unset
+	}
+
+	field := status.FieldByName("Code")
+	if !field.IsValid() || !field.CanInterface() {
+		return codes.Unset
+	}
+
+	code, _ := field.Interface().(codes.Code)
+	return code
+}
+
+// SpanErrorPolicy decides whether a zerolog event marks its span as errored, and
+// whether its error is recorded as an exception. err is nil if the event has no
+// error. See [WithSpanErrorPolicy].
+type SpanErrorPolicy func(level zerolog.Level, err error, fields map[string]any) bool
+
+// ErrorOnLevel returns a [SpanErrorPolicy] that matches events at the provided level or higher.
+func ErrorOnLevel(level zerolog.Level) SpanErrorPolicy {
+	return func(l zerolog.Level, _ error, _ map[string]any) bool {
+		return l >= level
+	}
+}
+
+// ErrorOnErr returns a [SpanErrorPolicy] that matches any event carrying an error
+// from `.Err()`, regardless of its level.
+func ErrorOnErr() SpanErrorPolicy {
+	return func(_ zerolog.Level, err error, _ map[string]any) bool {
+		return err != nil
+	}
+}
+
+// AnySpanErrorPolicy returns a [SpanErrorPolicy] that matches if any of the policies match.
+func AnySpanErrorPolicy(policies ...SpanErrorPolicy) SpanErrorPolicy {
+	return func(level zerolog.Level, err error, fields map[string]any) bool {
+		return slices.ContainsFunc(policies, func(policy SpanErrorPolicy) bool {
+			return policy(level, err, fields)
+		})
+	}
+}
+
+// IgnoreErrors returns a [SpanErrorPolicy] that matches like policy, except for events
+// whose error is one of errs, e.g. `IgnoreErrors(ErrorOnErr(), context.Canceled, io.EOF)`.
+//
+// Errors written through [Event.Err] are compared with errors.Is only. Errors written
+// straight to zerolog only keep their message, so they fall back to matching on text:
+// they match if the message is the same as, or ends with ": " and, the message of one
+// of errs. An untyped "parse header: EOF" is therefore ignored by IgnoreErrors(p, io.EOF)
+// even if it didn't wrap io.EOF.
+func IgnoreErrors(policy SpanErrorPolicy, errs ...error) SpanErrorPolicy {
+	return func(level zerolog.Level, err error, fields map[string]any) bool {
+		if err != nil && slices.ContainsFunc(errs, func(target error) bool { return matchError(err, target) }) {
+			return false
+		}
+		return policy(level, err, fields)
+	}
+}
+
+// matchError reports whether err matches target with errors.Is. Errors rebuilt from
+// the message zerolog wrote for them have no chain to match, so they fall back to
+// matching if their message is, or ends with ": " and, the message of target.
+func matchError(err error, target error) bool {
+	if errors.Is(err, target) {
+		return true
+	}
+
+	if _, ok := err.(messageError); !ok {
+		return false
+	}
+
+	msg, targetMsg := err.Error(), target.Error()
+	return msg == targetMsg || strings.HasSuffix(msg, ": "+targetMsg)
+}
@@ -0,0 +1,356 @@
+package otelzlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestLevelSpanEventFormatter(t *testing.T) {
+	attrs := []attribute.KeyValue{
+		attribute.String("level", "warn"),
+		attribute.String("key", "value"),
+	}
+
+	t.Run("all fields", func(t *testing.T) {
+		name, eventAttrs := LevelSpanEventFormatter()(zerolog.WarnLevel, "retrying", attrs)
+
+		assert.Equal(t, "log.warn", name)
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("log.message", "retrying"),
+			attribute.String("log.severity", "WARN"),
+			attribute.String("level", "warn"),
+			attribute.String("key", "value"),
+		}, eventAttrs)
+	})
+
+	t.Run("selected fields", func(t *testing.T) {
+		name, eventAttrs := LevelSpanEventFormatter("key")(zerolog.WarnLevel, "retrying", attrs)
+
+		assert.Equal(t, "log.warn", name)
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("log.message", "retrying"),
+			attribute.String("log.severity", "WARN"),
+			attribute.String("key", "value"),
+		}, eventAttrs)
+	})
+}
+
+func TestHookSpanEventFormatter(t *testing.T) {
+	recorder := newRecorder(t)
+	tracer := recorder.TracerProvider().Tracer("test")
+
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger:      recorder.Logger("test"),
+		attachSpanEvent: true,
+		spanEventFormat: LevelSpanEventFormatter("key"),
+	})
+
+	ctx, span := tracer.Start(t.Context(), "test.segment")
+	logger.Info().Ctx(ctx).Str("key", "value").Str("other", "value").Msg("test log")
+	span.End()
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "log.info", spans[0].Events()[0].Name)
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("log.message", "test log"),
+		attribute.String("log.severity", "INFO"),
+		attribute.String("key", "value"),
+	}, spans[0].Events()[0].Attributes)
+}
+
+func TestHookSpanStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		hook        *Hook
+		log         func(logger zerolog.Logger, ctx context.Context)
+		code        codes.Code
+		description string
+	}{
+		{
+			name: "error description from error",
+			hook: &Hook{setSpanError: true, setSpanErrorLevel: zerolog.ErrorLevel},
+			log: func(logger zerolog.Logger, ctx context.Context) {
+				logger.Error().Ctx(ctx).Err(errors.New("span: an error occurred")).Msg("test log")
+			},
+			code:        codes.Error,
+			description: "span: an error occurred",
+		},
+		{
+			name: "error description from message",
+			hook: &Hook{setSpanError: true, setSpanErrorLevel: zerolog.ErrorLevel},
+			log: func(logger zerolog.Logger, ctx context.Context) {
+				logger.Error().Ctx(ctx).Msg("test log")
+			},
+			code:        codes.Error,
+			description: "test log",
+		},
+		{
+			name: "custom formatter",
+			hook: &Hook{
+				setSpanError:      true,
+				setSpanErrorLevel: zerolog.ErrorLevel,
+				spanStatusFormat: func(level zerolog.Level, msg string, errMsg string) string {
+					return level.String() + ": " + msg + ": " + errMsg
+				},
+			},
+			log: func(logger zerolog.Logger, ctx context.Context) {
+				logger.Error().Ctx(ctx).Err(errors.New("span: an error occurred")).Msg("test log")
+			},
+			code:        codes.Error,
+			description: "error: test log: span: an error occurred",
+		},
+		{
+			name: "first error is kept",
+			hook: &Hook{setSpanError: true, setSpanErrorLevel: zerolog.WarnLevel},
+			log: func(logger zerolog.Logger, ctx context.Context) {
+				logger.Error().Ctx(ctx).Msg("first")
+				logger.Warn().Ctx(ctx).Msg("second")
+			},
+			code:        codes.Error,
+			description: "first",
+		},
+		{
+			name: "ok from level",
+			hook: &Hook{setSpanOk: true, setSpanOkLevel: zerolog.InfoLevel},
+			log: func(logger zerolog.Logger, ctx context.Context) {
+				logger.Info().Ctx(ctx).Msg("test log")
+			},
+			code: codes.Ok,
+		},
+		{
+			name: "ok from field",
+			hook: &Hook{setSpanOk: true, setSpanOkLevel: zerolog.Disabled, setSpanOkField: "success"},
+			log: func(logger zerolog.Logger, ctx context.Context) {
+				logger.Info().Ctx(ctx).Msg("not a success")
+				logger.Debug().Ctx(ctx).Bool("success", true).Msg("test log")
+			},
+			code: codes.Ok,
+		},
+		{
+			name: "ok does not downgrade error",
+			hook: &Hook{
+				setSpanError:      true,
+				setSpanErrorLevel: zerolog.ErrorLevel,
+				setSpanOk:         true,
+				setSpanOkLevel:    zerolog.InfoLevel,
+			},
+			log: func(logger zerolog.Logger, ctx context.Context) {
+				logger.Error().Ctx(ctx).Msg("test log")
+				logger.Info().Ctx(ctx).Msg("done")
+			},
+			code:        codes.Error,
+			description: "test log",
+		},
+		{
+			name: "error respects ok",
+			hook: &Hook{setSpanError: true, setSpanErrorLevel: zerolog.ErrorLevel},
+			log: func(logger zerolog.Logger, ctx context.Context) {
+				trace.SpanFromContext(ctx).SetStatus(codes.Ok, "")
+				logger.Error().Ctx(ctx).Msg("test log")
+			},
+			code: codes.Ok,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newRecorder(t)
+			tracer := recorder.TracerProvider().Tracer("test")
+
+			hook := tt.hook
+			hook.otelLogger = recorder.Logger("test")
+			logger := zerolog.New(io.Discard).Hook(hook)
+
+			ctx, span := tracer.Start(t.Context(), "test.segment")
+			tt.log(logger, ctx)
+			span.End()
+
+			spans := recorder.Spans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.code, spans[0].Status().Code)
+			assert.Equal(t, tt.description, spans[0].Status().Description)
+		})
+	}
+}
+
+// statusSpan is a recording span that isn't an SDK span, and so doesn't expose its status.
+type statusSpan struct {
+	noop.Span
+	codes []codes.Code
+}
+
+func (s *statusSpan) IsRecording() bool { return true }
+
+func (s *statusSpan) SpanContext() trace.SpanContext {
+	return trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}})
+}
+
+func (s *statusSpan) SetStatus(code codes.Code, _ string) { s.codes = append(s.codes, code) }
+
+func TestHookSpanStatusNonSDKSpan(t *testing.T) {
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger:        newRecorder(t).Logger("test"),
+		setSpanError:      true,
+		setSpanErrorLevel: zerolog.ErrorLevel,
+		setSpanOk:         true,
+		setSpanOkLevel:    zerolog.InfoLevel,
+	})
+
+	span := &statusSpan{}
+	ctx := trace.ContextWithSpan(t.Context(), span)
+	logger.Error().Ctx(ctx).Msg("test log")
+	logger.Info().Ctx(ctx).Msg("done")
+
+	assert.Equal(t, []codes.Code{codes.Error}, span.codes)
+}
+
+func TestSpanErrorPolicies(t *testing.T) {
+	wrapped := fmt.Errorf("span: reading body: %w", io.EOF)
+
+	tests := []struct {
+		name     string
+		policy   SpanErrorPolicy
+		level    zerolog.Level
+		err      error
+		expected bool
+	}{
+		{name: "level below", policy: ErrorOnLevel(zerolog.ErrorLevel), level: zerolog.WarnLevel, expected: false},
+		{name: "level at", policy: ErrorOnLevel(zerolog.ErrorLevel), level: zerolog.ErrorLevel, expected: true},
+		{name: "level above", policy: ErrorOnLevel(zerolog.ErrorLevel), level: zerolog.FatalLevel, expected: true},
+		{name: "err present", policy: ErrorOnErr(), level: zerolog.InfoLevel, err: errors.New("span: an error occurred"), expected: true},
+		{name: "err absent", policy: ErrorOnErr(), level: zerolog.ErrorLevel, expected: false},
+		{
+			name:     "any",
+			policy:   AnySpanErrorPolicy(ErrorOnErr(), ErrorOnLevel(zerolog.ErrorLevel)),
+			level:    zerolog.ErrorLevel,
+			expected: true,
+		},
+		{
+			name:     "any none",
+			policy:   AnySpanErrorPolicy(ErrorOnErr(), ErrorOnLevel(zerolog.ErrorLevel)),
+			level:    zerolog.WarnLevel,
+			expected: false,
+		},
+		{
+			name:     "ignore typed",
+			policy:   IgnoreErrors(ErrorOnErr(), io.EOF),
+			err:      wrapped,
+			expected: false,
+		},
+		{
+			name:     "ignore message",
+			policy:   IgnoreErrors(ErrorOnErr(), context.Canceled),
+			err:      messageError("span: request failed: context canceled"),
+			expected: false,
+		},
+		{
+			name:     "typed message",
+			policy:   IgnoreErrors(ErrorOnErr(), io.EOF),
+			err:      errors.New("span: parse header: EOF"),
+			expected: true,
+		},
+		{
+			name:     "ignore other",
+			policy:   IgnoreErrors(ErrorOnErr(), context.Canceled),
+			err:      wrapped,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy(tt.level, tt.err, nil))
+		})
+	}
+}
+
+func TestHookSpanErrorPolicy(t *testing.T) {
+	recorder := newRecorder(t)
+	tracer := recorder.TracerProvider().Tracer("test")
+
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger:      recorder.Logger("test"),
+		attachSpanEvent: true,
+		spanErrorPolicy: IgnoreErrors(ErrorOnErr(), context.Canceled),
+	})
+
+	// an ignored error is neither an exception nor an error status
+	ctx, span := tracer.Start(t.Context(), "segment.canceled")
+	NewEvent(logger.Warn().Ctx(ctx)).Err(fmt.Errorf("span: %w", context.Canceled)).Msg("canceled")
+	span.End()
+
+	// any other error is, even at info level
+	ctx, span = tracer.Start(t.Context(), "segment.failed")
+	logger.Info().Ctx(ctx).Err(errors.New("span: an error occurred")).Msg("failed")
+	span.End()
+
+	records := recorder.Records()
+	require.Len(t, records, 2)
+	assert.Empty(t, records[0].EventName())
+	assert.Equal(t, "exception", records[1].EventName())
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "canceled", spans[0].Events()[0].Name)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("level", "warn")}, spans[0].Events()[0].Attributes)
+
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "span: an error occurred", spans[1].Status().Description)
+	require.Len(t, spans[1].Events(), 1)
+	assert.Equal(t, "failed", spans[1].Events()[0].Name)
+	assert.Contains(t, spans[1].Events()[0].Attributes, attribute.String("exception.message", "span: an error occurred"))
+}
+
+func TestHookOrphanSpans(t *testing.T) {
+	recorder := newRecorder(t)
+	provider := recorder.TracerProvider()
+
+	logger := zerolog.New(io.Discard).Hook(&Hook{
+		otelLogger:      recorder.Logger("test"),
+		tracerProvider:  provider,
+		orphanSpans:     true,
+		orphanSpanLevel: zerolog.ErrorLevel,
+	})
+
+	// below the orphan level
+	logger.Warn().Ctx(t.Context()).Msg("warning")
+
+	// inside a span
+	ctx, span := provider.Tracer("test").Start(t.Context(), "test.segment")
+	logger.Error().Ctx(ctx).Msg("in span")
+	span.End()
+
+	// orphaned
+	logger.Error().Ctx(t.Context()).Err(errors.New("span: an error occurred")).Msg("orphaned")
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "test.segment", spans[0].Name())
+	assert.Empty(t, spans[0].Events())
+
+	assert.Equal(t, "orphaned", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "span: an error occurred", spans[1].Status().Description)
+	require.Len(t, spans[1].Events(), 1)
+	assert.Equal(t, "orphaned", spans[1].Events()[0].Name)
+
+	records := recorder.Records()
+	require.Len(t, records, 3)
+	assert.Equal(t, spans[1].SpanContext(), records[2].SpanContext)
+}